@@ -30,41 +30,86 @@ var supportedKnownTimeFormats = []string{
 	time.StampNano,
 }
 
+// knownTimeFormatNames are the names of supportedKnownTimeFormats, as in the
+// names of the functions to parse them.
+var knownTimeFormatNames = map[string]string{
+	time.RFC822:      "rfc822",
+	time.RFC822Z:     "rfc822z",
+	time.RFC850:      "rfc850",
+	time.RFC1123:     "rfc1123",
+	time.RFC1123Z:    "rfc1123z",
+	time.RFC3339:     "rfc3339",
+	time.RFC3339Nano: "rfc3339nano",
+	time.ANSIC:       "ansic",
+	time.UnixDate:    "unixdate",
+	time.RubyDate:    "rubydate",
+	time.Kitchen:     "kitchen",
+	time.Stamp:       "stamp",
+	time.StampMilli:  "stampmilli",
+	time.StampMicro:  "stampmicro",
+	time.StampNano:   "stampnano",
+}
+
 func Guess(v interface{}, args []interface{}) interface{} {
 	if len(args) == 1 {
 		v = args[0]
 	}
+	t, _ := guess(v)
+	return t
+}
 
+// GuessExplain returns the time object which guess generates and the name of
+// the format or the kind of identifier which it recognized.
+func GuessExplain(v interface{}, args []interface{}) interface{} {
+	if len(args) == 1 {
+		v = args[0]
+	}
+	t, format := guess(v)
+	if err, ok := t.(error); ok {
+		return err
+	}
+	return map[string]interface{}{
+		"format": format,
+		"time":   t,
+	}
+}
+
+func guess(v interface{}) (interface{}, string) {
 	if isLikelyUnix(v) {
-		return FromUnix(v, args)
+		return FromUnix(v, nil), "unix"
 	}
 
 	if isLikelyUnixMilli(v) {
-		return FromUnixMilli(v, args)
+		return FromUnixMilli(v, nil), "unixmilli"
 	}
 
 	if isLikelyUnixMicro(v) {
-		return FromUnixMicro(v, args)
+		return FromUnixMicro(v, nil), "unixmicro"
 	}
 
 	if isLikelyUnixNano(v) {
-		return FromUnixNano(v, args)
+		if _, ok := v.(float64); ok {
+			// interpreted as Unix time in seconds with fractions
+			return FromUnixNano(v, nil), "unix"
+		}
+		return FromUnixNano(v, nil), "unixnano"
 	}
 
 	if s, ok := v.(string); ok {
-		for _, f := range supportedKnownTimeFormats {
-			t := timeFromString(f, s)
-			if _, ok := t.(error); !ok {
-				return t
-			}
-		}
+		return guessTimeFromString(s, assumedLocation)
 	}
 
-	return errors.New("unable to guess")
+	return errors.New("unable to guess"), ""
 }
 
 var reAllDigits = regexp.MustCompile("^[[:digit:]]+$")
 
+// IsAllDigits reports whether the string consists of digits only, which
+// guess may take as Unix time.
+func IsAllDigits(s string) bool {
+	return reAllDigits.MatchString(s)
+}
+
 func isLikelyUnix(v interface{}) bool {
 	lenNow := len(fmt.Sprintf("%d", time.Now().Unix()))
 	switch x := v.(type) {
@@ -274,7 +319,7 @@ func FromYMD(_ any, args []any) any {
 		return errors.Errorf("unexpected argument type for day. expected int but found %T", args[2])
 	}
 
-	return EncapTime(time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local))
+	return encapLocalTime(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
 }
 
 func FromYMDTimeZone(_ any, args []any) any {
@@ -303,12 +348,12 @@ func FromYMDTimeZone(_ any, args []any) any {
 		return errors.Errorf("unexpected argument type for timezone. expected string but found %T", args[3])
 	}
 
-	tz, err := time.LoadLocation(tzStr)
+	tz, err := loadLocation(tzStr)
 	if err != nil {
 		return errors.Errorf("unable to load timezone '%s': %v", tzStr, err)
 	}
 
-	return EncapTime(time.Date(year, time.Month(month), day, 0, 0, 0, 0, tz))
+	return encapLocalTime(year, time.Month(month), day, 0, 0, 0, 0, tz)
 }
 
 func FromYMDHMS(_ any, args []any) any {
@@ -346,7 +391,7 @@ func FromYMDHMS(_ any, args []any) any {
 		return errors.Errorf("unexpected argument type for second. expected int but found %T", args[5])
 	}
 
-	return EncapTime(time.Date(year, time.Month(month), day, hour, minute, second, 0, time.Local))
+	return encapLocalTime(year, time.Month(month), day, hour, minute, second, 0, time.Local)
 }
 
 func FromYMDHMSTimeZone(_ any, args []any) any {
@@ -389,12 +434,12 @@ func FromYMDHMSTimeZone(_ any, args []any) any {
 		return errors.Errorf("unexpected argument type for timezone. expected string but found %T", args[6])
 	}
 
-	tz, err := time.LoadLocation(tzStr)
+	tz, err := loadLocation(tzStr)
 	if err != nil {
 		return errors.Errorf("unable to load timezone '%s': %v", tzStr, err)
 	}
 
-	return EncapTime(time.Date(year, time.Month(month), day, hour, minute, second, 0, tz))
+	return encapLocalTime(year, time.Month(month), day, hour, minute, second, 0, tz)
 }
 
 type BuiltinFn func(interface{}, []interface{}) interface{}
@@ -434,11 +479,11 @@ func getTimeArg(v interface{}, args []interface{}) (*time.Time, bool) {
 }
 
 func timeFromString(layout, value string) interface{} {
-	t, err := time.Parse(layout, value)
+	t, status, err := parseTimeIn(layout, value, assumedLocation)
 	if err != nil {
-		return errors.New("unable to parse using the specified format")
+		return err
 	}
-	return EncapTime(t)
+	return EncapLocalTime(t, status)
 }
 
 func AddDate(v interface{}, args []interface{}) interface{} {
@@ -467,10 +512,6 @@ func AddDate(v interface{}, args []interface{}) interface{} {
 }
 
 func Add(v interface{}, args []interface{}) interface{} {
-	t, ok := DecapTime(v)
-	if !ok {
-		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
-	}
 	if len(args) < 1 {
 		return errors.New("insufficient arguments")
 	}
@@ -480,7 +521,17 @@ func Add(v interface{}, args []interface{}) interface{} {
 		return errors.Errorf("expected duration as the first argument, but found unexpected type: %T", args[0])
 	}
 
-	return EncapTime(t.Add(*d))
+	if t, ok := DecapTime(v); ok {
+		return EncapTime(t.Add(*d))
+	}
+	if e, ok := DecapDuration(v); ok {
+		x, err := addDurations(*e, *d)
+		if err != nil {
+			return err
+		}
+		return EncapDuration(x)
+	}
+	return errors.Errorf("expected time or duration as input, but found unexpected type: %T", v)
 }
 
 func Sub(v any, args []any) any {
@@ -558,6 +609,20 @@ func InDays(v any, args []any) any {
 	return d.Hours() / 24
 }
 
+func Weeks(v interface{}, args []interface{}) interface{} {
+	if len(args) == 1 {
+		v = args[0]
+	}
+	return convertToDuration(v, 7*24*time.Hour)
+}
+
+func Days(v interface{}, args []interface{}) interface{} {
+	if len(args) == 1 {
+		v = args[0]
+	}
+	return convertToDuration(v, 24*time.Hour)
+}
+
 func Hours(v interface{}, args []interface{}) interface{} {
 	if len(args) == 1 {
 		v = args[0]
@@ -602,7 +667,7 @@ func Nanoseconds(v interface{}, args []interface{}) interface{} {
 
 func Today(_ interface{}, _ []interface{}) interface{} {
 	t := time.Now()
-	return EncapTime(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local))
+	return encapLocalTime(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
 func TodayUTC(_ interface{}, _ []interface{}) interface{} {
@@ -612,7 +677,7 @@ func TodayUTC(_ interface{}, _ []interface{}) interface{} {
 
 func Yesterday(_ interface{}, _ []interface{}) interface{} {
 	t := time.Now().AddDate(0, 0, -1)
-	return EncapTime(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local))
+	return encapLocalTime(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
 func YesterdayUTC(_ interface{}, _ []interface{}) interface{} {
@@ -622,7 +687,7 @@ func YesterdayUTC(_ interface{}, _ []interface{}) interface{} {
 
 func Tomorrow(_ interface{}, _ []interface{}) interface{} {
 	t := time.Now().AddDate(0, 0, 1)
-	return EncapTime(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local))
+	return encapLocalTime(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
 }
 
 func TomorrowUTC(_ interface{}, _ []interface{}) interface{} {
@@ -631,24 +696,31 @@ func TomorrowUTC(_ interface{}, _ []interface{}) interface{} {
 }
 
 func convertToDuration(v interface{}, unit time.Duration) interface{} {
-	x, ok := v.(int)
-	if !ok {
-		return errors.Errorf("expected integer, but found unexpected type: %T", v)
+	switch x := v.(type) {
+	case int:
+		return EncapDuration(time.Duration(x) * unit)
+	case float64:
+		d, err := scaleDuration(unit, x)
+		if err != nil {
+			return err
+		}
+		return EncapDuration(d)
+	default:
+		return errors.Errorf("expected number, but found unexpected type: %T", v)
 	}
-
-	return EncapDuration(time.Duration(x) * unit)
 }
 
 func EncapTime(t time.Time) map[string]interface{} {
 	zoneName, offset := t.Zone()
 	year := t.Year()
-	return map[string]interface{}{
-		"__dq__source":    t,
-		"unixNano":        t.UnixNano(),
+	names := locales[defaultLocale]
+	m := map[string]interface{}{
+		sourceKey:         timeSource(t),
+		"unixNano":        int(t.UnixNano()),
 		"unixNanoString":  fmt.Sprintf("%d", t.UnixNano()),
-		"unixMicro":       t.UnixMicro(),
+		"unixMicro":       int(t.UnixMicro()),
 		"unixMicroString": fmt.Sprintf("%d", t.UnixMicro()),
-		"unixMilli":       t.UnixMilli(),
+		"unixMilli":       int(t.UnixMilli()),
 		"unixMilliString": fmt.Sprintf("%d", t.UnixMilli()),
 		"unix":            int(t.Unix()),
 		"unixString":      fmt.Sprintf("%d", t.Unix()),
@@ -669,13 +741,58 @@ func EncapTime(t time.Time) map[string]interface{} {
 			"dst":           t.IsDST(),
 		},
 		"weekday": map[string]interface{}{
-			"name": t.Weekday().String(),
+			"name":      t.Weekday().String(),
+			"localName": names.weekdays[t.Weekday()],
 		},
-		"dayOfYear":   t.YearDay(),
-		"daysInMonth": getDaysInMonth(t),
-		"rfc3339":     t.Format(time.RFC3339),
-		"leapYear":    year%4 == 0 && (year%100 != 0 || year%400 == 0),
+		"monthName":         t.Month().String(),
+		"monthLocalName":    names.months[t.Month()-1],
+		"dayOfYear":         t.YearDay(),
+		"daysInMonth":       getDaysInMonth(t),
+		"rfc3339":           t.Format(time.RFC3339),
+		"leapYear":          year%4 == 0 && (year%100 != 0 || year%400 == 0),
+		"leapSecondsBefore": leapSecondsBefore(t),
+	}
+	if includeEra {
+		m["era"] = encapEra(t)
+	}
+	if fiscal != nil {
+		m["fiscal"] = encapFiscal(t)
+	}
+	if includeEpochs {
+		m["epochs"] = encapEpochs(t)
+	}
+	return m
+}
+
+// yearInferenceTolerance is how far in the future a timestamp without year
+// is allowed to be, to absorb clock skew among hosts.
+const yearInferenceTolerance = 7 * 24 * time.Hour
+
+// DateInInferredYear returns the time of the specified month, day and clock
+// in the most recent year which does not make it (noticeably) later than now.
+// This is used to complement the year of timestamps without it (e.g. BSD
+// syslog), so that "Dec 31" seen on January 1 belongs to the previous year
+// and "Jan 1" seen a few seconds before the new year belongs to the next one.
+// The local time is interpreted by the DST policy, and its status is also
+// returned (see localTime).
+func DateInInferredYear(month time.Month, day, hour, min, sec, nsec int, loc *time.Location, now time.Time) (time.Time, string, bool) {
+	var result time.Time
+	var status string
+	found := false
+	year := now.In(loc).Year()
+	for _, y := range []int{year - 1, year, year + 1} {
+		if d := time.Date(y, month, day, 0, 0, 0, 0, time.UTC); d.Month() != month || d.Day() != day {
+			continue // e.g. Feb 29 in a non-leap year
+		}
+		t, s, err := localTime(y, month, day, hour, min, sec, nsec, loc)
+		if err != nil {
+			continue
+		}
+		if !found || t.Sub(now) <= yearInferenceTolerance {
+			result, status, found = t, s, true
+		}
 	}
+	return result, status, found
 }
 
 func getDaysInMonth(t time.Time) int {
@@ -692,12 +809,12 @@ func DecapTime(v interface{}) (*time.Time, bool) {
 		return nil, false
 	}
 
-	source, ok := m["__dq__source"]
+	source, ok := m[sourceKey]
 	if !ok {
 		return nil, false
 	}
 
-	t, ok := source.(time.Time)
+	t, ok := parseTimeSource(source)
 	if !ok {
 		return nil, false
 	}
@@ -707,13 +824,14 @@ func DecapTime(v interface{}) (*time.Time, bool) {
 
 func EncapDuration(d time.Duration) map[string]interface{} {
 	return map[string]interface{}{
-		"__dq__source": d,
+		sourceKey:      durationSource(d),
 		"hours":        d.Hours(),
 		"minutes":      d.Minutes(),
 		"seconds":      d.Seconds(),
-		"milliseconds": d.Milliseconds(),
-		"microseconds": d.Microseconds(),
-		"nanoseconds":  d.Nanoseconds(),
+		"milliseconds": int(d.Milliseconds()),
+		"microseconds": int(d.Microseconds()),
+		"nanoseconds":  int(d.Nanoseconds()),
+		"components":   durationComponents(d),
 	}
 }
 
@@ -723,12 +841,12 @@ func DecapDuration(v interface{}) (*time.Duration, bool) {
 		return nil, false
 	}
 
-	source, ok := m["__dq__source"]
+	source, ok := m[sourceKey]
 	if !ok {
 		return nil, false
 	}
 
-	d, ok := source.(time.Duration)
+	d, ok := parseDurationSource(source)
 	if !ok {
 		return nil, false
 	}
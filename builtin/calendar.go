@@ -0,0 +1,408 @@
+package builtin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Dates are handled as fixed day numbers (R.D.) in this file and chinese.go,
+// where day 1 is January 1, 1 in the proleptic Gregorian calendar, following
+// "Calendrical Calculations" by Reingold and Dershowitz.
+
+const rdUnixEpoch = 719163 // 1970-01-01
+
+// maxCalendarYear is the bound of the years of the calendars which
+// FromCalendar accepts before converting them.
+const maxCalendarYear = 20000
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func floorMod(a, b int) int {
+	return a - b*floorDiv(a, b)
+}
+
+func fixedFromGregorian(year int, month time.Month, day int) int {
+	return floorDiv(int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()), 86400) + rdUnixEpoch
+}
+
+func gregorianFromFixed(d int) (int, time.Month, int) {
+	return time.Unix(int64(d-rdUnixEpoch)*86400, 0).UTC().Date()
+}
+
+// calendarMonth is a month of a year in a calendar. The code is in the
+// style of the month codes of ECMAScript Temporal, e.g. M05L for a leap
+// month after the fifth month.
+type calendarMonth struct {
+	code  string
+	name  string
+	start int
+	days  int
+}
+
+type calendarSystem struct {
+	// yearOf returns the year which the day belongs to.
+	yearOf func(d int) (int, error)
+	// months returns the months of the year in order.
+	months func(year int) ([]calendarMonth, error)
+	// extra adds calendar specific fields to the converted date.
+	extra func(m map[string]interface{}, year int, months []calendarMonth)
+}
+
+var calendarSystems = map[string]*calendarSystem{
+	"islamic": islamicCalendar,
+	"hijri":   islamicCalendar,
+	"hebrew":  hebrewCalendar,
+	"persian": persianCalendar,
+	"jalali":  persianCalendar,
+	"chinese": chineseCalendar,
+}
+
+func getCalendarSystem(v interface{}) (string, *calendarSystem, error) {
+	name, ok := v.(string)
+	if !ok {
+		return "", nil, errors.Errorf("expected string as calendar name, but found unexpected type: %T", v)
+	}
+	name = strings.ToLower(name)
+	c, ok := calendarSystems[name]
+	if !ok {
+		return "", nil, errors.Errorf("unsupported calendar: %s (expected islamic, hebrew, persian or chinese)", name)
+	}
+	switch name {
+	case "hijri":
+		name = "islamic"
+	case "jalali":
+		name = "persian"
+	}
+	return name, c, nil
+}
+
+func monthCode(n int, leap bool) string {
+	if leap {
+		return fmt.Sprintf("M%02dL", n)
+	}
+	return fmt.Sprintf("M%02d", n)
+}
+
+// ToCalendar converts the date of the time in its timezone to the date in
+// the calendar.
+func ToCalendar(v interface{}, args []interface{}) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	name, c, err := getCalendarSystem(args[0])
+	if err != nil {
+		return err
+	}
+
+	d := fixedFromGregorian(t.Date())
+	year, err := c.yearOf(d)
+	if err != nil {
+		return err
+	}
+	months, err := c.months(year)
+	if err != nil {
+		return err
+	}
+	for i, month := range months {
+		if d < month.start || d >= month.start+month.days {
+			continue
+		}
+		m := map[string]interface{}{
+			"calendar":     name,
+			"year":         year,
+			"month":        i + 1,
+			"monthCode":    month.code,
+			"monthName":    month.name,
+			"leapMonth":    strings.HasSuffix(month.code, "L"),
+			"day":          d - month.start + 1,
+			"daysInMonth":  month.days,
+			"monthsInYear": len(months),
+			"daysInYear":   months[len(months)-1].start + months[len(months)-1].days - months[0].start,
+		}
+		if c.extra != nil {
+			c.extra(m, year, months)
+		}
+		return m
+	}
+	return errors.Errorf("unable to convert to %s calendar: %s", name, t.Format("2006-01-02"))
+}
+
+// FromCalendar returns the time at midnight in local time of the date in the
+// calendar. The month is either the ordinal number of the month in the year
+// or the month code (e.g. M05L).
+func FromCalendar(_ interface{}, args []interface{}) interface{} {
+	name, c, err := getCalendarSystem(args[0])
+	if err != nil {
+		return err
+	}
+	year, ok := args[1].(int)
+	if !ok {
+		return errors.Errorf("expected integer as year, but found unexpected type: %T", args[1])
+	}
+	day, ok := args[3].(int)
+	if !ok {
+		return errors.Errorf("expected integer as day, but found unexpected type: %T", args[3])
+	}
+	// years of every calendar for the range of times of FromEpoch are within
+	// this bound, which keeps the calculations from overflowing
+	if year < -maxCalendarYear || year > maxCalendarYear {
+		return errors.Errorf("out of range of %s calendar: %d", name, year)
+	}
+	months, err := c.months(year)
+	if err != nil {
+		return err
+	}
+
+	var month *calendarMonth
+	switch m := args[2].(type) {
+	case int:
+		if m >= 1 && m <= len(months) {
+			month = &months[m-1]
+		}
+	case string:
+		for i := range months {
+			if months[i].code == strings.ToUpper(m) {
+				month = &months[i]
+			}
+		}
+	default:
+		return errors.Errorf("expected integer or month code as month, but found unexpected type: %T", args[2])
+	}
+	if month == nil {
+		return errors.Errorf("no such month in %d of %s calendar: %v", year, name, args[2])
+	}
+	if day < 1 || day > month.days {
+		return errors.Errorf("no such day in %s %d of %s calendar: %d", month.name, year, name, day)
+	}
+
+	fixed := month.start + day - 1
+	if sec := int64(fixed-rdUnixEpoch) * 86400; sec < minEpochTime || sec >= maxEpochTime {
+		return errors.Errorf("out of range of %s calendar: %d", name, year)
+	}
+	y, m, d := gregorianFromFixed(fixed)
+	return encapLocalTime(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// Islamic calendar (the arithmetic one, a.k.a. tabular Islamic calendar)
+
+const islamicEpoch = 227015 // July 16, 622 (Julian)
+
+var islamicMonthNames = []string{
+	"Muharram", "Safar", "Rabi' al-Awwal", "Rabi' al-Thani", "Jumada al-Ula", "Jumada al-Akhirah",
+	"Rajab", "Sha'ban", "Ramadan", "Shawwal", "Dhu al-Qa'dah", "Dhu al-Hijjah",
+}
+
+func isIslamicLeapYear(year int) bool {
+	return floorMod(14+11*year, 30) < 11
+}
+
+func fixedFromIslamic(year, month, day int) int {
+	return day + 29*(month-1) + floorDiv(6*month-1, 11) + (year-1)*354 + floorDiv(3+11*year, 30) + islamicEpoch - 1
+}
+
+var islamicCalendar = &calendarSystem{
+	yearOf: func(d int) (int, error) {
+		return floorDiv(30*(d-islamicEpoch)+10646, 10631), nil
+	},
+	months: func(year int) ([]calendarMonth, error) {
+		months := make([]calendarMonth, 12)
+		for i := range months {
+			days := 30 - i%2
+			if i == 11 && isIslamicLeapYear(year) {
+				days = 30
+			}
+			months[i] = calendarMonth{monthCode(i+1, false), islamicMonthNames[i], fixedFromIslamic(year, i+1, 1), days}
+		}
+		return months, nil
+	},
+	extra: func(m map[string]interface{}, year int, _ []calendarMonth) {
+		m["leapYear"] = isIslamicLeapYear(year)
+	},
+}
+
+// Hebrew calendar. The months are numbered from Nisan as in "Calendrical
+// Calculations" internally, though the ordinal months in the results start
+// from Tishrei as the year does.
+
+const hebrewEpoch = -1373427 // October 7, 3761 B.C.E. (Julian)
+
+func isHebrewLeapYear(year int) bool {
+	return floorMod(7*year+1, 19) < 7
+}
+
+func hebrewCalendarElapsedDays(year int) int {
+	monthsElapsed := floorDiv(235*year-234, 19)
+	partsElapsed := 12084 + 13753*monthsElapsed
+	days := 29*monthsElapsed + floorDiv(partsElapsed, 25920)
+	if floorMod(3*(days+1), 7) < 3 {
+		return days + 1
+	}
+	return days
+}
+
+func hebrewNewYear(year int) int {
+	ny0 := hebrewCalendarElapsedDays(year - 1)
+	ny1 := hebrewCalendarElapsedDays(year)
+	ny2 := hebrewCalendarElapsedDays(year + 1)
+	correction := 0
+	if ny2-ny1 == 356 {
+		correction = 2
+	} else if ny1-ny0 == 382 {
+		correction = 1
+	}
+	return hebrewEpoch + ny1 + correction
+}
+
+func lastDayOfHebrewMonth(month, year int) int {
+	daysInYear := hebrewNewYear(year+1) - hebrewNewYear(year)
+	switch {
+	case month == 2 || month == 4 || month == 6 || month == 10 || month == 13,
+		month == 12 && !isHebrewLeapYear(year),
+		month == 8 && daysInYear%10 != 5, // Heshvan is long in years of 355 or 385 days
+		month == 9 && daysInYear%10 == 3: // Kislev is short in years of 353 or 383 days
+		return 29
+	}
+	return 30
+}
+
+var hebrewMonthNames = []string{
+	"Nisan", "Iyar", "Sivan", "Tammuz", "Av", "Elul",
+	"Tishrei", "Heshvan", "Kislev", "Tevet", "Shevat", "Adar", "Adar II",
+}
+
+var hebrewCalendar = &calendarSystem{
+	yearOf: func(d int) (int, error) {
+		year := floorDiv((d-hebrewEpoch)*98496, 35975351)
+		for hebrewNewYear(year+1) <= d {
+			year++
+		}
+		return year, nil
+	},
+	months: func(year int) ([]calendarMonth, error) {
+		leap := isHebrewLeapYear(year)
+		order := []int{7, 8, 9, 10, 11, 12, 1, 2, 3, 4, 5, 6}
+		if leap {
+			order = []int{7, 8, 9, 10, 11, 12, 13, 1, 2, 3, 4, 5, 6}
+		}
+		months := make([]calendarMonth, len(order))
+		start := hebrewNewYear(year)
+		for i, m := range order {
+			n := i + 1
+			name := hebrewMonthNames[m-1]
+			code := monthCode(n, false)
+			if leap {
+				switch {
+				case m == 12:
+					name, code = "Adar I", monthCode(5, true)
+				case i > 5:
+					code = monthCode(n-1, false)
+				}
+			}
+			days := lastDayOfHebrewMonth(m, year)
+			months[i] = calendarMonth{code, name, start, days}
+			start += days
+		}
+		return months, nil
+	},
+	extra: func(m map[string]interface{}, year int, _ []calendarMonth) {
+		m["leapYear"] = isHebrewLeapYear(year)
+	},
+}
+
+// Persian (Jalali) calendar by the algorithm of Kazimierz M. Borkowski, which
+// agrees with the astronomical calendar in use for years 1178 to 1633 AP.
+
+var jalaliBreaks = []int{
+	-61, 9, 38, 199, 426, 686, 756, 818, 1111, 1181, 1210,
+	1635, 2060, 2097, 2192, 2262, 2324, 2394, 2456, 3178,
+}
+
+// jalaliCalendar returns whether the year is a leap year and the day of
+// March in the Gregorian calendar on which the year begins.
+func jalaliCalendar(year int) (bool, int, error) {
+	if year < jalaliBreaks[0] || year >= jalaliBreaks[len(jalaliBreaks)-1] {
+		return false, 0, errors.Errorf("year out of range of persian calendar: %d", year)
+	}
+	gy := year + 621
+	leapJ := -14
+	jp := jalaliBreaks[0]
+	jump := 0
+	for _, jm := range jalaliBreaks[1:] {
+		jump = jm - jp
+		if year < jm {
+			break
+		}
+		leapJ += jump/33*8 + jump%33/4
+		jp = jm
+	}
+	n := year - jp
+	leapJ += n/33*8 + (n%33+3)/4
+	if jump%33 == 4 && jump-n == 4 {
+		leapJ++
+	}
+	leapG := gy/4 - (gy/100+1)*3/4 - 150
+	march := 20 + leapJ - leapG
+
+	if jump-n < 6 {
+		n = n - jump + (jump+4)/33*33
+	}
+	leap := ((n+1)%33 - 1) % 4
+	if leap == -1 {
+		leap = 4
+	}
+	return leap == 0, march, nil
+}
+
+var persianMonthNames = []string{
+	"Farvardin", "Ordibehesht", "Khordad", "Tir", "Mordad", "Shahrivar",
+	"Mehr", "Aban", "Azar", "Dey", "Bahman", "Esfand",
+}
+
+var persianCalendar = &calendarSystem{
+	yearOf: func(d int) (int, error) {
+		gy, _, _ := gregorianFromFixed(d)
+		year := gy - 621
+		_, march, err := jalaliCalendar(year)
+		if err != nil {
+			return 0, err
+		}
+		if d < fixedFromGregorian(gy, time.March, march) {
+			year--
+		}
+		return year, nil
+	},
+	months: func(year int) ([]calendarMonth, error) {
+		leap, march, err := jalaliCalendar(year)
+		if err != nil {
+			return nil, err
+		}
+		months := make([]calendarMonth, 12)
+		start := fixedFromGregorian(year+621, time.March, march)
+		for i := range months {
+			days := 31
+			switch {
+			case i == 11 && !leap:
+				days = 29
+			case i >= 6:
+				days = 30
+			}
+			months[i] = calendarMonth{monthCode(i+1, false), persianMonthNames[i], start, days}
+			start += days
+		}
+		return months, nil
+	},
+	extra: func(m map[string]interface{}, year int, _ []calendarMonth) {
+		leap, _, _ := jalaliCalendar(year)
+		m["leapYear"] = leap
+	},
+}
@@ -0,0 +1,397 @@
+package builtin
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Chinese lunisolar calendar by the rules in use since 1645, following
+// "Calendrical Calculations". The moments of new moons are computed by the
+// algorithm in "Astronomical Algorithms" by Jean Meeus, and the solar
+// longitude by its low accuracy one (about 0.01 degrees), which are accurate
+// enough for the dates in the foreseeable past and future.
+
+const (
+	meanSynodicMonth = 29.530588861
+	meanTropicalYear = 365.242189
+	jdFixedEpoch     = 1721424.5 // the Julian day number of midnight of R.D. 0
+)
+
+var rdChinaStandardTime = fixedFromGregorian(1929, time.January, 1)
+
+func deg2rad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func fmod(x, y float64) float64 {
+	return x - y*math.Floor(x/y)
+}
+
+// deltaT returns the difference between TT and UT in seconds, by the
+// polynomials of Espenak and Meeus.
+func deltaT(jd float64) float64 {
+	y := 2000 + (jd-2451545)/365.25
+	switch {
+	case y >= 1800 && y < 1860:
+		t := y - 1800
+		return 13.72 - 0.332447*t + 0.0068612*t*t + 0.0041116*math.Pow(t, 3) - 0.00037436*math.Pow(t, 4) +
+			0.0000121272*math.Pow(t, 5) - 0.0000001699*math.Pow(t, 6) + 0.000000000875*math.Pow(t, 7)
+	case y >= 1860 && y < 1900:
+		t := y - 1860
+		return 7.62 + 0.5737*t - 0.251754*t*t + 0.01680668*math.Pow(t, 3) - 0.0004473624*math.Pow(t, 4) + math.Pow(t, 5)/233174
+	case y >= 1900 && y < 1920:
+		t := y - 1900
+		return -2.79 + 1.494119*t - 0.0598939*t*t + 0.0061966*math.Pow(t, 3) - 0.000197*math.Pow(t, 4)
+	case y >= 1920 && y < 1941:
+		t := y - 1920
+		return 21.20 + 0.84493*t - 0.076100*t*t + 0.0020936*math.Pow(t, 3)
+	case y >= 1941 && y < 1961:
+		t := y - 1950
+		return 29.07 + 0.407*t - t*t/233 + math.Pow(t, 3)/2547
+	case y >= 1961 && y < 1986:
+		t := y - 1975
+		return 45.45 + 1.067*t - t*t/260 - math.Pow(t, 3)/718
+	case y >= 1986 && y < 2005:
+		t := y - 2000
+		return 63.86 + 0.3345*t - 0.060374*t*t + 0.0017275*math.Pow(t, 3) + 0.000651814*math.Pow(t, 4) + 0.00002373599*math.Pow(t, 5)
+	case y >= 2005 && y < 2050:
+		t := y - 2000
+		return 62.92 + 0.32217*t + 0.005589*t*t
+	case y >= 2050 && y < 2150:
+		u := (y - 1820) / 100
+		return -20 + 32*u*u - 0.5628*(2150-y)
+	}
+	u := (y - 1820) / 100
+	return -20 + 32*u*u
+}
+
+// solarLongitude returns the apparent longitude of the sun in degrees at the
+// moment in Julian day (UT).
+func solarLongitude(jd float64) float64 {
+	t := (jd + deltaT(jd)/86400 - 2451545) / 36525
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := deg2rad(357.52911 + 35999.05029*t - 0.0001537*t*t)
+	c := (1.914602-0.004817*t-0.000014*t*t)*math.Sin(m) + (0.019993-0.000101*t)*math.Sin(2*m) + 0.000289*math.Sin(3*m)
+	omega := deg2rad(125.04 - 1934.136*t)
+	return fmod(l0+c-0.00569-0.00478*math.Sin(omega), 360)
+}
+
+// nthNewMoon returns the moment in Julian day (UT) of the k-th new moon
+// since January 6, 2000.
+func nthNewMoon(k float64) float64 {
+	t := k / 1236.85
+	jde := 2451550.09766 + meanSynodicMonth*k + 0.00015437*t*t - 0.000000150*math.Pow(t, 3) + 0.00000000073*math.Pow(t, 4)
+	e := 1 - 0.002516*t - 0.0000074*t*t
+	m := deg2rad(2.5534 + 29.10535670*k - 0.0000014*t*t - 0.00000011*math.Pow(t, 3))
+	mp := deg2rad(201.5643 + 385.81693528*k + 0.0107582*t*t + 0.00001238*math.Pow(t, 3) - 0.000000058*math.Pow(t, 4))
+	f := deg2rad(160.7108 + 390.67050284*k - 0.0016118*t*t - 0.00000227*math.Pow(t, 3) + 0.000000011*math.Pow(t, 4))
+	omega := deg2rad(124.7746 - 1.56375588*k + 0.0020672*t*t + 0.00000215*math.Pow(t, 3))
+
+	jde += -0.40720*math.Sin(mp) +
+		0.17241*e*math.Sin(m) +
+		0.01608*math.Sin(2*mp) +
+		0.01039*math.Sin(2*f) +
+		0.00739*e*math.Sin(mp-m) -
+		0.00514*e*math.Sin(mp+m) +
+		0.00208*e*e*math.Sin(2*m) -
+		0.00111*math.Sin(mp-2*f) -
+		0.00057*math.Sin(mp+2*f) +
+		0.00056*e*math.Sin(2*mp+m) -
+		0.00042*math.Sin(3*mp) +
+		0.00042*e*math.Sin(m+2*f) +
+		0.00038*e*math.Sin(m-2*f) -
+		0.00024*e*math.Sin(2*mp-m) -
+		0.00017*math.Sin(omega) -
+		0.00007*math.Sin(mp+2*m) +
+		0.00004*math.Sin(2*mp-2*f) +
+		0.00004*math.Sin(3*m) +
+		0.00003*math.Sin(mp+m-2*f) +
+		0.00003*math.Sin(2*mp+2*f) -
+		0.00003*math.Sin(mp+m+2*f) +
+		0.00003*math.Sin(mp-m+2*f) -
+		0.00002*math.Sin(mp-m-2*f) -
+		0.00002*math.Sin(3*mp+m) +
+		0.00002*math.Sin(4*mp)
+
+	planetary := []struct{ coef, a0, a1 float64 }{
+		{0.000325, 299.77, 0.107408}, {0.000165, 251.88, 0.016321}, {0.000164, 251.83, 26.651886},
+		{0.000126, 349.42, 36.412478}, {0.000110, 84.66, 18.206239}, {0.000062, 141.74, 53.303771},
+		{0.000060, 207.14, 2.453732}, {0.000056, 154.84, 7.306860}, {0.000047, 34.52, 27.261239},
+		{0.000042, 207.19, 0.121824}, {0.000040, 291.34, 1.844379}, {0.000037, 161.72, 24.198154},
+		{0.000035, 239.56, 25.513099}, {0.000023, 331.55, 3.592518},
+	}
+	for i, p := range planetary {
+		a := p.a0 + p.a1*k
+		if i == 0 {
+			a -= 0.009173 * t * t
+		}
+		jde += p.coef * math.Sin(deg2rad(a))
+	}
+	return jde - deltaT(jde)/86400
+}
+
+// maxSearchSteps limits the steps of the searches for new moons and solar
+// terms, which stop making progress at the precision of float64 for dates
+// far from now.
+const maxSearchSteps = 100
+
+var errSearchNotConverged = errors.New("out of range of Chinese calendar")
+
+func newMoonAtOrAfter(jd float64) (float64, error) {
+	k := math.Floor((jd-2451550.09766)/meanSynodicMonth) - 1
+	for i := 0; i < maxSearchSteps; i++ {
+		if m := nthNewMoon(k); m >= jd {
+			return m, nil
+		}
+		k++
+	}
+	return 0, errSearchNotConverged
+}
+
+func newMoonBefore(jd float64) (float64, error) {
+	k := math.Ceil((jd-2451550.09766)/meanSynodicMonth) + 1
+	for i := 0; i < maxSearchSteps; i++ {
+		if m := nthNewMoon(k); m < jd {
+			return m, nil
+		}
+		k--
+	}
+	return 0, errSearchNotConverged
+}
+
+// chinaZone returns the offset from UT in days, which is the local mean time
+// of Beijing before 1929.
+func chinaZone(d int) float64 {
+	if d < rdChinaStandardTime {
+		return 1397.0 / 180 / 24
+	}
+	return 8.0 / 24
+}
+
+func midnightInChina(d int) float64 {
+	return float64(d) + jdFixedEpoch - chinaZone(d)
+}
+
+func fixedInChina(jd float64) int {
+	d := int(math.Floor(jd - jdFixedEpoch))
+	return int(math.Floor(jd + chinaZone(d) - jdFixedEpoch))
+}
+
+func chineseNewMoonOnOrAfter(d int) (int, error) {
+	jd, err := newMoonAtOrAfter(midnightInChina(d))
+	if err != nil {
+		return 0, err
+	}
+	return fixedInChina(jd), nil
+}
+
+func chineseNewMoonBefore(d int) (int, error) {
+	jd, err := newMoonBefore(midnightInChina(d))
+	if err != nil {
+		return 0, err
+	}
+	return fixedInChina(jd), nil
+}
+
+// currentMajorSolarTerm returns the index of the last major solar term
+// (zhongqi) on or before the day, e.g. 11 for the winter solstice.
+func currentMajorSolarTerm(d int) int {
+	s := int(math.Floor(solarLongitude(midnightInChina(d)) / 30))
+	return floorMod(2+s-1, 12) + 1
+}
+
+func chineseNoMajorSolarTerm(d int) (bool, error) {
+	next, err := chineseNewMoonOnOrAfter(d + 1)
+	if err != nil {
+		return false, err
+	}
+	return currentMajorSolarTerm(d) == currentMajorSolarTerm(next), nil
+}
+
+func chinesePriorLeapMonth(start, d int) (bool, error) {
+	for d >= start {
+		noTerm, err := chineseNoMajorSolarTerm(d)
+		if err != nil {
+			return false, err
+		}
+		if noTerm {
+			return true, nil
+		}
+		if d, err = chineseNewMoonBefore(d); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func chineseWinterSolsticeOnOrBefore(d int) (int, error) {
+	// estimate the moment of the solstice, and search the day from just
+	// before it
+	rate := meanTropicalYear / 360
+	moment := midnightInChina(d + 1)
+	tau := moment - rate*fmod(solarLongitude(moment)-270, 360)
+	tau -= rate * (fmod(solarLongitude(tau)-270+180, 360) - 180)
+	day := fixedInChina(tau) - 1
+	for i := 0; i < maxSearchSteps; i++ {
+		l := solarLongitude(midnightInChina(day + 1))
+		if l > 270 && l < 300 {
+			return day, nil
+		}
+		day++
+	}
+	return 0, errSearchNotConverged
+}
+
+// chineseSui returns the beginning of the month 12 in the sui (the period
+// between two winter solstices) including the day, and whether the sui has
+// a leap month.
+func chineseSui(d int) (int, bool, error) {
+	s1, err := chineseWinterSolsticeOnOrBefore(d)
+	if err != nil {
+		return 0, false, err
+	}
+	s2, err := chineseWinterSolsticeOnOrBefore(s1 + 370)
+	if err != nil {
+		return 0, false, err
+	}
+	m12, err := chineseNewMoonOnOrAfter(s1 + 1)
+	if err != nil {
+		return 0, false, err
+	}
+	nextM11, err := chineseNewMoonBefore(s2 + 1)
+	if err != nil {
+		return 0, false, err
+	}
+	return m12, math.Round(float64(nextM11-m12)/meanSynodicMonth) == 12, nil
+}
+
+func chineseNewYearInSui(d int) (int, error) {
+	m12, leap, err := chineseSui(d)
+	if err != nil {
+		return 0, err
+	}
+	m13, err := chineseNewMoonOnOrAfter(m12 + 1)
+	if err != nil {
+		return 0, err
+	}
+	if leap {
+		noTerm12, err := chineseNoMajorSolarTerm(m12)
+		if err != nil {
+			return 0, err
+		}
+		noTerm13, err := chineseNoMajorSolarTerm(m13)
+		if err != nil {
+			return 0, err
+		}
+		if noTerm12 || noTerm13 {
+			return chineseNewMoonOnOrAfter(m13 + 1)
+		}
+	}
+	return m13, nil
+}
+
+func chineseNewYearOnOrBefore(d int) (int, error) {
+	newYear, err := chineseNewYearInSui(d)
+	if err != nil {
+		return 0, err
+	}
+	if d >= newYear {
+		return newYear, nil
+	}
+	return chineseNewYearInSui(d - 180)
+}
+
+// chineseMonthOf returns the number of the month beginning on the day, and
+// whether it is a leap month.
+func chineseMonthOf(d int) (int, bool, error) {
+	m12, leapYear, err := chineseSui(d)
+	if err != nil {
+		return 0, false, err
+	}
+	month := int(math.Round(float64(d-m12) / meanSynodicMonth))
+	if !leapYear {
+		return floorMod(month-1, 12) + 1, false, nil
+	}
+	prior, err := chinesePriorLeapMonth(m12, d)
+	if err != nil {
+		return 0, false, err
+	}
+	if prior {
+		month--
+	}
+	month = floorMod(month-1, 12) + 1
+	noTerm, err := chineseNoMajorSolarTerm(d)
+	if err != nil {
+		return 0, false, err
+	}
+	if !noTerm {
+		return month, false, nil
+	}
+	prev, err := chineseNewMoonBefore(d)
+	if err != nil {
+		return 0, false, err
+	}
+	prior, err = chinesePriorLeapMonth(m12, prev)
+	if err != nil {
+		return 0, false, err
+	}
+	return month, !prior, nil
+}
+
+var chineseMonthNames = []string{
+	"正月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月",
+}
+
+var (
+	chineseStems    = []string{"甲", "乙", "丙", "丁", "戊", "己", "庚", "辛", "壬", "癸"}
+	chineseBranches = []string{"子", "丑", "寅", "卯", "辰", "巳", "午", "未", "申", "酉", "戌", "亥"}
+	chineseZodiac   = []string{"Rat", "Ox", "Tiger", "Rabbit", "Dragon", "Snake", "Horse", "Goat", "Monkey", "Rooster", "Dog", "Pig"}
+)
+
+// chineseCalendar numbers the years by the Gregorian year in which they
+// begin, as ECMAScript Temporal (and ICU) does.
+var chineseCalendar = &calendarSystem{
+	yearOf: func(d int) (int, error) {
+		newYear, err := chineseNewYearOnOrBefore(d)
+		if err != nil {
+			return 0, err
+		}
+		year, _, _ := gregorianFromFixed(newYear)
+		return year, nil
+	},
+	months: func(year int) ([]calendarMonth, error) {
+		start, err := chineseNewYearOnOrBefore(fixedFromGregorian(year, time.July, 1))
+		if err != nil {
+			return nil, err
+		}
+		next, err := chineseNewYearOnOrBefore(fixedFromGregorian(year+1, time.July, 1))
+		if err != nil {
+			return nil, err
+		}
+		var months []calendarMonth
+		for start < next {
+			end, err := chineseNewMoonOnOrAfter(start + 1)
+			if err != nil {
+				return nil, err
+			}
+			n, leap, err := chineseMonthOf(start)
+			if err != nil {
+				return nil, err
+			}
+			name := chineseMonthNames[n-1]
+			if leap {
+				name = "闰" + name
+			}
+			months = append(months, calendarMonth{monthCode(n, leap), name, start, end - start})
+			start = end
+		}
+		return months, nil
+	},
+	extra: func(m map[string]interface{}, year int, months []calendarMonth) {
+		m["leapYear"] = len(months) == 13
+		m["yearName"] = chineseStems[floorMod(year-4, 10)] + chineseBranches[floorMod(year-4, 12)]
+		m["zodiac"] = chineseZodiac[floorMod(year-4, 12)]
+	},
+}
@@ -0,0 +1,105 @@
+package builtin
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// dstPolicy decides how local times which are ambiguous (e.g. 01:30 on a
+// fall-back night) or nonexistent (e.g. 02:30 on a spring-forward night) due
+// to DST transitions are interpreted.
+//
+//   - earlier: the earlier of the two instants
+//   - later: the later of the two instants
+//   - error: neither, but an error
+//   - shift_forward: the earlier one if ambiguous, and the time shifted
+//     forward by the length of the gap if nonexistent (e.g. 03:30)
+//
+// For nonexistent times, the two instants are those interpreted with the
+// offsets before and after the transition, e.g. 01:30 and 03:30.
+var dstPolicy = "shift_forward"
+
+// SetDSTPolicy sets the policy for ambiguous and nonexistent local times.
+func SetDSTPolicy(policy string) error {
+	switch policy {
+	case "earlier", "later", "error", "shift_forward":
+		dstPolicy = policy
+		return nil
+	}
+	return errors.Errorf("unknown DST policy: %s (expected earlier, later, error or shift_forward)", policy)
+}
+
+// the values of dst_status of time objects
+const (
+	dstStatusAmbiguous = "ambiguous"
+	dstStatusGap       = "gap"
+)
+
+// localTime works as time.Date does, but interprets ambiguous and
+// nonexistent local times by dstPolicy, and also returns which of them the
+// local time was, if any.
+func localTime(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) (time.Time, string, error) {
+	wall := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+	unix := wall.Unix()
+
+	// DST transitions are assumed not to occur twice within 2 days
+	_, before := time.Unix(unix-86400, 0).In(loc).Zone()
+	_, after := time.Unix(unix+86400, 0).In(loc).Zone()
+	candidate := func(offset int) (time.Time, bool) {
+		t := time.Unix(unix-int64(offset), int64(wall.Nanosecond())).In(loc)
+		_, o := t.Zone()
+		return t, o == offset
+	}
+	t1, ok1 := candidate(before)
+	t2, ok2 := candidate(after)
+	if before == after || ok1 != ok2 {
+		if ok2 && !ok1 {
+			return t2, "", nil
+		}
+		return t1, "", nil
+	}
+
+	earlier, later := t1, t2
+	if later.Before(earlier) {
+		earlier, later = later, earlier
+	}
+	status := dstStatusAmbiguous
+	if !ok1 {
+		status = dstStatusGap
+	}
+	switch dstPolicy {
+	case "earlier":
+		return earlier, status, nil
+	case "later":
+		return later, status, nil
+	case "error":
+		if status == dstStatusGap {
+			return time.Time{}, "", errors.Errorf("nonexistent local time in %s: %s", loc, wall.Format("2006-01-02 15:04:05.999999999"))
+		}
+		return time.Time{}, "", errors.Errorf("ambiguous local time in %s: %s", loc, wall.Format("2006-01-02 15:04:05.999999999"))
+	}
+	if status == dstStatusGap {
+		return later, status, nil
+	}
+	return earlier, status, nil
+}
+
+// EncapLocalTime returns the time object of the time constructed by
+// localTime, with dst_status if the local time was ambiguous or nonexistent.
+func EncapLocalTime(t time.Time, status string) map[string]interface{} {
+	m := EncapTime(t)
+	if status != "" {
+		m["dst_status"] = status
+	}
+	return m
+}
+
+// encapLocalTime is a shorthand for the constructors of time objects.
+func encapLocalTime(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) interface{} {
+	t, status, err := localTime(year, month, day, hour, min, sec, nsec, loc)
+	if err != nil {
+		return err
+	}
+	return EncapLocalTime(t, status)
+}
@@ -0,0 +1,120 @@
+package builtin
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// durationComponents breaks the duration down into days, hours, minutes,
+// seconds and nanoseconds for display, e.g. 1d 2h 3m 4s. The components are
+// of the absolute value, and negative tells the sign.
+func durationComponents(d time.Duration) map[string]interface{} {
+	negative := d < 0
+	// use unsigned value not to overflow on the minimum duration
+	u := uint64(d)
+	if negative {
+		u = -u
+	}
+	day := uint64(24 * time.Hour)
+	return map[string]interface{}{
+		"negative":    negative,
+		"days":        int(u / day),
+		"hours":       int(u % day / uint64(time.Hour)),
+		"minutes":     int(u % uint64(time.Hour) / uint64(time.Minute)),
+		"seconds":     int(u % uint64(time.Minute) / uint64(time.Second)),
+		"nanoseconds": int(u % uint64(time.Second)),
+	}
+}
+
+func getDurationInput(v interface{}) (*time.Duration, error) {
+	d, ok := DecapDuration(v)
+	if !ok {
+		return nil, errors.Errorf("expected duration as input, but found unexpected type: %T", v)
+	}
+	return d, nil
+}
+
+func Negate(v interface{}, _ []interface{}) interface{} {
+	d, err := getDurationInput(v)
+	if err != nil {
+		return err
+	}
+	if *d == minDuration {
+		return errors.New("duration out of range")
+	}
+	return EncapDuration(-*d)
+}
+
+const minDuration time.Duration = -1 << 63
+
+// Abs returns the absolute value of a duration, or of a number for
+// convenience.
+func Abs(v interface{}, _ []interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		if x < 0 {
+			return -x
+		}
+		return x
+	case float64:
+		if x < 0 {
+			return -x
+		}
+		return x
+	case *big.Int:
+		return new(big.Int).Abs(x)
+	}
+	d, err := getDurationInput(v)
+	if err != nil {
+		return err
+	}
+	if *d == minDuration {
+		return errors.New("duration out of range")
+	}
+	return EncapDuration(d.Abs())
+}
+
+func Scale(v interface{}, args []interface{}) interface{} {
+	d, err := getDurationInput(v)
+	if err != nil {
+		return err
+	}
+	f, ok := toFloat(args[0])
+	if !ok {
+		return errors.Errorf("expected number as the first argument, but found unexpected type: %T", args[0])
+	}
+	x, err := scaleDuration(*d, f)
+	if err != nil {
+		return err
+	}
+	return EncapDuration(x)
+}
+
+// roundFunc returns a function which rounds a time or a duration to a
+// multiple of the unit given as a duration. Times are rounded as Go does, i.e.
+// relative to the zero time, so units longer than an hour may not be aligned
+// with days in the local time zone.
+func roundFunc(roundTime func(time.Time, time.Duration) time.Time, roundDuration func(time.Duration, time.Duration) time.Duration) func(interface{}, []interface{}) interface{} {
+	return func(v interface{}, args []interface{}) interface{} {
+		unit, ok := DecapDuration(args[0])
+		if !ok {
+			return errors.Errorf("expected duration as the first argument, but found unexpected type: %T", args[0])
+		}
+		if *unit <= 0 {
+			return errors.New("expected positive duration as the first argument")
+		}
+		if t, ok := DecapTime(v); ok {
+			return EncapTime(roundTime(*t, *unit))
+		}
+		if d, ok := DecapDuration(v); ok {
+			return EncapDuration(roundDuration(*d, *unit))
+		}
+		return errors.Errorf("expected time or duration as input, but found unexpected type: %T", v)
+	}
+}
+
+var Truncate = roundFunc(time.Time.Truncate, time.Duration.Truncate)
+
+var Round = roundFunc(time.Time.Round, time.Duration.Round)
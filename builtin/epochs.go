@@ -0,0 +1,293 @@
+package builtin
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// epoch is a representation of time as a number of units elapsed since an
+// epoch, other than Unix time.
+type epoch struct {
+	name  string
+	label string // for error messages
+	from  func(x float64, i int64, isInt bool) (time.Time, string, error)
+	to    func(t time.Time) (interface{}, error)
+	// min and max are the range of numbers which FromEpoch converts
+	min, max float64
+}
+
+// epochs are the epochs in the order of the fields of the epochs sub-object
+// of time objects.
+var epochs = []*epoch{
+	// a day before the epoch, as the serial dates count 1900-02-29
+	newEpoch("excel", "Excel 1900 date system", fromExcel1900, toExcel1900, excel1900Epoch.Unix()-86400, 86400),
+	newEpoch("excel1904", "Excel 1904 date system", fromSerial(excel1904Epoch), toSerial(excel1904Epoch), excel1904Epoch.Unix(), 86400),
+	newEpoch("ole", "OLE Automation date", fromOLE, toOLE, oleEpoch.Unix(), 86400),
+	newEpoch("filetime", "FILETIME", fromTicks(epochYear1601, 100), toTicks(epochYear1601, 100), epochYear1601, 1e-7),
+	newEpoch("ticks", ".NET ticks", fromTicks(epochYear1, 100), toTicks(epochYear1, 100), epochYear1, 1e-7),
+	newEpoch("apple", "Mac absolute time", fromSeconds(epochApple, 1), toSeconds(epochApple, 1), epochApple, 1),
+	newEpoch("webkit", "WebKit time", fromTicks(epochYear1601, 1000), toTicks(epochYear1601, 1000), epochYear1601, 1e-6),
+	newEpoch("jd", "Julian Day", fromSeconds(epochJulianDay, 86400), toSeconds(epochJulianDay, 86400), epochJulianDay, 86400),
+	newEpoch("mjd", "Modified Julian Day", fromSeconds(epochModifiedJulianDay, 86400), toSeconds(epochModifiedJulianDay, 86400), epochModifiedJulianDay, 86400),
+}
+
+// the range of times which FromEpoch returns, i.e. years from -9999 to 9999
+var (
+	minEpochTime = time.Date(-9999, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	maxEpochTime = time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+// newEpoch returns the epoch of units (in seconds) since the epoch (in Unix
+// time), which converts the numbers within the range of times.
+func newEpoch(name, label string,
+	from func(float64, int64, bool) (time.Time, string, error), to func(time.Time) (interface{}, error),
+	epochUnix int64, unit float64) *epoch {
+	return &epoch{
+		name:  name,
+		label: label,
+		from:  from,
+		to:    to,
+		min:   float64(minEpochTime-epochUnix) / unit,
+		max:   float64(maxEpochTime-epochUnix) / unit,
+	}
+}
+
+// the epochs in Unix time
+const (
+	epochYear1             = -62135596800  // 0001-01-01T00:00:00Z, of .NET DateTime
+	epochYear1601          = -11644473600  // 1601-01-01T00:00:00Z, of Windows FILETIME and WebKit
+	epochApple             = 978307200     // 2001-01-01T00:00:00Z, of Cocoa (Mac absolute time)
+	epochJulianDay         = -210866760000 // -4713-11-24T12:00:00Z (proleptic Gregorian)
+	epochModifiedJulianDay = -3506716800   // 1858-11-17T00:00:00Z
+)
+
+// the epochs of the serial dates, whose wall clock is in the time zone of
+// --assume-tz
+var (
+	excel1900Epoch = date(1899, time.December, 31)
+	oleEpoch       = date(1899, time.December, 30)
+	excel1904Epoch = date(1904, time.January, 1)
+)
+
+// includeEpochs tells EncapTime to add the epochs sub-object to time objects.
+var includeEpochs = false
+
+// SetIncludeEpochs sets whether time objects have the epochs sub-object.
+func SetIncludeEpochs(b bool) {
+	includeEpochs = b
+}
+
+func encapEpochs(t time.Time) map[string]interface{} {
+	m := map[string]interface{}{}
+	for _, e := range epochs {
+		v, err := e.convert(t)
+		if err != nil {
+			v = nil
+		}
+		m[e.name] = v
+	}
+	return m
+}
+
+func getEpoch(name string) *epoch {
+	for _, e := range epochs {
+		if e.name == name {
+			return e
+		}
+	}
+	panic("unknown epoch: " + name)
+}
+
+// FromEpoch returns the function which converts numbers (or numeric strings)
+// since the epoch to time objects.
+func FromEpoch(name string) BuiltinFn {
+	e := getEpoch(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		if len(args) == 1 {
+			v = args[0]
+		}
+		var (
+			x     float64
+			i     int64
+			isInt bool
+		)
+		switch n := v.(type) {
+		case int:
+			x, i, isInt = float64(n), int64(n), true
+		case float64:
+			x = n
+			if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+				i, isInt = int64(n), true
+			}
+		case string:
+			var err error
+			if i, err = strconv.ParseInt(n, 10, 64); err == nil {
+				x, isInt = float64(i), true
+			} else if x, err = strconv.ParseFloat(n, 64); err != nil {
+				return errors.Errorf("expected number as input, but found: %s", n)
+			}
+		default:
+			return errors.Errorf("expected number as input, but found unexpected type: %T", v)
+		}
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return errors.Errorf("expected finite number as input, but found: %v", x)
+		}
+		if x < e.min || x >= e.max {
+			return errors.Errorf("out of range of %s: %v", e.label, v)
+		}
+		t, status, err := e.from(x, i, isInt)
+		if err != nil {
+			return err
+		}
+		return EncapLocalTime(t, status)
+	}
+}
+
+// ToEpoch returns the function which converts time objects to numbers since
+// the epoch.
+func ToEpoch(name string) BuiltinFn {
+	e := getEpoch(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		t, ok := getTimeArg(v, args)
+		if !ok {
+			return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+		}
+		n, err := e.convert(*t)
+		if err != nil {
+			return err
+		}
+		return n
+	}
+}
+
+// convert converts the time within the range of times of FromEpoch to the
+// number since the epoch, which keeps e.g. ticks from overflowing.
+func (e *epoch) convert(t time.Time) (interface{}, error) {
+	if sec := t.Unix(); sec < minEpochTime || sec >= maxEpochTime {
+		return nil, errors.Errorf("out of range of %s: %s", e.label, t.Format(time.RFC3339))
+	}
+	return e.to(t)
+}
+
+// fromTicks and toTicks are for integers of units (in nanoseconds) since the
+// epoch in UTC, e.g. FILETIME.
+func fromTicks(epochUnix, unit int64) func(float64, int64, bool) (time.Time, string, error) {
+	perSecond := int64(time.Second) / unit
+	return func(x float64, i int64, isInt bool) (time.Time, string, error) {
+		if !isInt {
+			i = int64(math.Round(x))
+		}
+		sec, rem := i/perSecond, i%perSecond
+		if rem < 0 {
+			sec, rem = sec-1, rem+perSecond
+		}
+		return time.Unix(sec+epochUnix, rem*unit), "", nil
+	}
+}
+
+func toTicks(epochUnix, unit int64) func(time.Time) (interface{}, error) {
+	perSecond := int64(time.Second) / unit
+	return func(t time.Time) (interface{}, error) {
+		return int((t.Unix()-epochUnix)*perSecond + int64(t.Nanosecond())/unit), nil
+	}
+}
+
+// fromSeconds and toSeconds are for real numbers of units (in seconds) since
+// the epoch in UTC, e.g. Julian Day. Fractions of days are rounded to
+// microseconds, which is about the precision of them.
+func fromSeconds(epochUnix int64, unit float64) func(float64, int64, bool) (time.Time, string, error) {
+	return func(x float64, _ int64, _ bool) (time.Time, string, error) {
+		whole, frac := math.Modf(x)
+		ns := frac * unit * 1e9
+		if unit > 1 {
+			ns = math.Round(ns/1e3) * 1e3
+		}
+		t := time.Unix(epochUnix+int64(whole)*int64(unit), 0).Add(time.Duration(math.Round(ns)))
+		return t, "", nil
+	}
+}
+
+func toSeconds(epochUnix int64, unit float64) func(time.Time) (interface{}, error) {
+	return func(t time.Time) (interface{}, error) {
+		sec := t.Unix() - epochUnix
+		whole := float64(sec / int64(unit))
+		return whole + (float64(sec%int64(unit))+float64(t.Nanosecond())/1e9)/unit, nil
+	}
+}
+
+// wallDays returns the number of days since the epoch (as a date in UTC) and
+// the fraction of the day of the wall clock of the time.
+func wallDays(t time.Time, epoch time.Time) (int, float64) {
+	days := fixedFromGregorian(t.Date()) - fixedFromGregorian(epoch.Date())
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	return days, clock.Seconds() / 86400
+}
+
+// serialTime returns the time of the wall clock of the days since the epoch,
+// in the zone specified by --assume-tz.
+func serialTime(epoch time.Time, days int, frac float64) (time.Time, string, error) {
+	wall := epoch.AddDate(0, 0, days).Add(time.Duration(math.Round(frac*86400e6)) * time.Microsecond)
+	return localTime(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), assumedLocation)
+}
+
+// fromSerial and toSerial are for serial dates of spreadsheets, which count
+// days since the epoch on the wall clock in the time zone of --assume-tz.
+func fromSerial(epoch time.Time) func(float64, int64, bool) (time.Time, string, error) {
+	return func(x float64, _ int64, _ bool) (time.Time, string, error) {
+		days := math.Floor(x)
+		return serialTime(epoch, int(days), x-days)
+	}
+}
+
+func toSerial(epoch time.Time) func(time.Time) (interface{}, error) {
+	return func(t time.Time) (interface{}, error) {
+		days, frac := wallDays(t, epoch)
+		return float64(days) + frac, nil
+	}
+}
+
+// Excel 1900 date system, where 1 is 1900-01-01, and 60 is 1900-02-29 which
+// does not exist, as Lotus 1-2-3 treated 1900 as a leap year.
+func fromExcel1900(x float64, _ int64, _ bool) (time.Time, string, error) {
+	days := math.Floor(x)
+	switch {
+	case days < 0:
+		return time.Time{}, "", errors.Errorf("out of range of Excel 1900 date system: %v", x)
+	case days == 60:
+		return time.Time{}, "", errors.Errorf("nonexistent date in Excel 1900 date system (1900-02-29): %v", x)
+	case days > 60:
+		days--
+	}
+	return serialTime(excel1900Epoch, int(days), x-math.Floor(x))
+}
+
+func toExcel1900(t time.Time) (interface{}, error) {
+	days, frac := wallDays(t, excel1900Epoch)
+	if days < 0 {
+		return nil, errors.Errorf("out of range of Excel 1900 date system: %s", t.Format(time.RFC3339))
+	}
+	if days >= 60 {
+		days++
+	}
+	return float64(days) + frac, nil
+}
+
+// OLE Automation dates, where the integer part is the days since the epoch
+// and the fraction is the time of the day even if negative, e.g. -1.25 is
+// 1899-12-29 06:00.
+func fromOLE(x float64, _ int64, _ bool) (time.Time, string, error) {
+	days, frac := math.Modf(x)
+	return serialTime(oleEpoch, int(days), math.Abs(frac))
+}
+
+func toOLE(t time.Time) (interface{}, error) {
+	days, frac := wallDays(t, oleEpoch)
+	if days < 0 {
+		return float64(days) - frac, nil
+	}
+	return float64(days) + frac, nil
+}
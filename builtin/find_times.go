@@ -0,0 +1,194 @@
+package builtin
+
+import (
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itchyny/gojq"
+	"github.com/pkg/errors"
+)
+
+type timeFinder struct {
+	format string
+	re     *regexp.Regexp
+	group  int                                     // index of the submatch holding the timestamp, 0 for the whole match
+	parse  func(string) (time.Time, string, error) // returns dst_status as well
+}
+
+var timeFinders = []timeFinder{
+	{
+		format: "rfc3339",
+		re:     regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`),
+		parse:  parseEmbeddedRFC3339,
+	},
+	{
+		format: "clf",
+		re:     regexp.MustCompile(`\b\d{2}/(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}`),
+		parse: func(s string) (time.Time, string, error) {
+			t, err := time.Parse("02/Jan/2006:15:04:05 -0700", s)
+			return t, "", err
+		},
+	},
+	{
+		format: "syslog",
+		re:     regexp.MustCompile(`\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) [ \d]\d \d{2}:\d{2}:\d{2}(?:\.\d+)?\b`),
+		parse:  parseEmbeddedStamp,
+	},
+	{
+		format: "unix",
+		re:     regexp.MustCompile(`\b[A-Za-z_][\w.\-]*=["']?(\d{10}(?:\d{3}){0,3}(?:\.\d+)?)\b`),
+		group:  1,
+		parse: func(s string) (time.Time, string, error) {
+			t, err := parseEmbeddedEpoch(s)
+			return t, "", err
+		},
+	},
+}
+
+func parseEmbeddedRFC3339(s string) (time.Time, string, error) {
+	s = strings.Replace(s, " ", "T", 1)
+	s = strings.Replace(s, ",", ".", 1)
+	if strings.HasSuffix(s, "Z") {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, "", err
+	}
+	if i := strings.LastIndexAny(s, "+-"); i > len("2006-01-02") {
+		if zone := s[i:]; !strings.Contains(zone, ":") {
+			s = s[:i] + zone[:3] + ":" + zone[3:]
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, "", err
+	}
+	t, err := time.Parse("2006-01-02T15:04:05.999999999", s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	return localTime(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), assumedLocation)
+}
+
+func parseEmbeddedStamp(s string) (time.Time, string, error) {
+	t, err := time.Parse(time.Stamp, s)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	t, status, ok := DateInInferredYear(t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), assumedLocation, time.Now())
+	if !ok {
+		return time.Time{}, "", errors.New("no such date")
+	}
+	return t, status, nil
+}
+
+// parseEmbeddedEpoch interprets a Unix time whose resolution is decided by
+// the number of integral digits, as guess does.
+func parseEmbeddedEpoch(s string) (time.Time, error) {
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	var unit int64
+	switch len(intPart) {
+	case 10:
+		unit = int64(time.Second)
+	case 13:
+		unit = int64(time.Millisecond)
+	case 16:
+		unit = int64(time.Microsecond)
+	case 19:
+		unit = int64(time.Nanosecond)
+	default:
+		return time.Time{}, errors.Errorf("unexpected number of digits: %s", s)
+	}
+	r, ok := new(big.Rat).SetString(intPart + "." + fracPart + "0")
+	if !ok {
+		return time.Time{}, errors.Errorf("unable to parse number: %s", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(unit))
+	ns := new(big.Int).Quo(r.Num(), r.Denom())
+	if !ns.IsInt64() {
+		return time.Time{}, errors.Errorf("out of range: %s", s)
+	}
+	return time.Unix(0, ns.Int64()), nil
+}
+
+type foundTime struct {
+	format     string
+	start, end int
+	t          time.Time
+	dstStatus  string
+}
+
+func findTimes(s string) []foundTime {
+	var found []foundTime
+	for _, f := range timeFinders {
+		for _, loc := range f.re.FindAllStringSubmatchIndex(s, -1) {
+			start, end := loc[2*f.group], loc[2*f.group+1]
+			t, status, err := f.parse(s[start:end])
+			if err != nil {
+				continue
+			}
+			format := f.format
+			if format == "unix" {
+				format = epochFormatName(s[start:end])
+			}
+			found = append(found, foundTime{format, start, end, t, status})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].start != found[j].start {
+			return found[i].start < found[j].start
+		}
+		return found[i].end > found[j].end
+	})
+
+	// drop matches overlapping with a preceding (or longer) one
+	result := found[:0]
+	end := 0
+	for _, f := range found {
+		if f.start < end {
+			continue
+		}
+		result = append(result, f)
+		end = f.end
+	}
+	return result
+}
+
+func epochFormatName(s string) string {
+	intPart, _, _ := strings.Cut(s, ".")
+	switch len(intPart) {
+	case 13:
+		return "unixmilli"
+	case 16:
+		return "unixmicro"
+	case 19:
+		return "unixnano"
+	default:
+		return "unix"
+	}
+}
+
+// FindTimes scans a free text for embedded timestamps and emits a time object
+// for each of them. The offsets in the `match` field are counted in code
+// points, the same as jq's string functions do.
+func FindTimes(v interface{}, args []interface{}) gojq.Iter {
+	s, ok := getStringArg(v, args)
+	if !ok {
+		return gojq.NewIter(errors.Errorf("expected string, but found unexpected type: %T", v))
+	}
+
+	found := findTimes(s)
+	results := make([]interface{}, 0, len(found))
+	for _, f := range found {
+		m := EncapLocalTime(f.t, f.dstStatus)
+		m["match"] = map[string]interface{}{
+			"format": f.format,
+			"text":   s[f.start:f.end],
+			"start":  utf8.RuneCountInString(s[:f.start]),
+			"end":    utf8.RuneCountInString(s[:f.end]),
+		}
+		results = append(results, m)
+	}
+	return gojq.NewIter(results...)
+}
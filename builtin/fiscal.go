@@ -0,0 +1,236 @@
+package builtin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// fiscalCalendar describes how fiscal years are divided. Fiscal years of a
+// monthly calendar begin on the first day of startMonth, and consist of 12
+// months as periods. Those of a week-based (retail) calendar begin on the
+// Sunday nearest to the first day of startMonth, and consist of 52 or 53
+// weeks, where the periods in each quarter have the weeks in the pattern
+// (e.g. 4, 4, 5) and the 53rd week is added to the last period.
+type fiscalCalendar struct {
+	startMonth time.Month
+	pattern    []int
+	nameByEnd  bool
+}
+
+// fiscal is the fiscal calendar configured by SetFiscalCalendar, which adds
+// fiscal fields to time objects.
+var fiscal *fiscalCalendar
+
+var defaultFiscalCalendar = &fiscalCalendar{startMonth: time.January}
+
+func currentFiscalCalendar() *fiscalCalendar {
+	if fiscal != nil {
+		return fiscal
+	}
+	return defaultFiscalCalendar
+}
+
+var fiscalPatterns = map[string][]int{
+	"monthly": nil,
+	"4-4-5":   {4, 4, 5},
+	"4-5-4":   {4, 5, 4},
+	"5-4-4":   {5, 4, 4},
+}
+
+func parseMonth(s string) (time.Month, bool) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Month(n), n >= 1 && n <= 12
+	}
+	for m := time.January; m <= time.December; m++ {
+		if len(s) >= 3 && strings.HasPrefix(strings.ToLower(m.String()), strings.ToLower(s)) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+// SetFiscalCalendar configures the fiscal calendar by the month in which
+// fiscal years begin (e.g. "4" or "apr"), the calendar ("monthly", "4-4-5",
+// "4-5-4" or "5-4-4") and how fiscal years are named ("start" or "end", i.e.
+// by the calendar year in which they begin or end).
+func SetFiscalCalendar(start, calendar, name string) error {
+	c := &fiscalCalendar{startMonth: time.January}
+	if start != "" {
+		m, ok := parseMonth(start)
+		if !ok {
+			return errors.Errorf("invalid month for fiscal year start: %s", start)
+		}
+		c.startMonth = m
+	}
+	if calendar != "" {
+		pattern, ok := fiscalPatterns[calendar]
+		if !ok {
+			return errors.Errorf("unknown fiscal calendar: %s (expected monthly, 4-4-5, 4-5-4 or 5-4-4)", calendar)
+		}
+		c.pattern = pattern
+	}
+	switch name {
+	case "", "end":
+		c.nameByEnd = true
+	case "start":
+	default:
+		return errors.Errorf("unknown fiscal year naming: %s (expected start or end)", name)
+	}
+	fiscal = c
+	return nil
+}
+
+// date returns the midnight of the day in UTC, which the calculations in
+// this file use as dates regardless of the timezone.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// yearStart returns the first day of the fiscal year which begins in the
+// calendar year.
+func (c *fiscalCalendar) yearStart(year int) time.Time {
+	d := date(year, c.startMonth, 1)
+	if c.pattern == nil {
+		return d
+	}
+	// the nearest Sunday
+	offset := -int(d.Weekday())
+	if offset < -3 {
+		offset += 7
+	}
+	return d.AddDate(0, 0, offset)
+}
+
+type fiscalYear struct {
+	name int
+	// periods has the first days of the 12 periods and the next year
+	periods []time.Time
+}
+
+func (c *fiscalCalendar) yearOf(d time.Time) *fiscalYear {
+	year := d.Year() + 1
+	for c.yearStart(year).After(d) {
+		year--
+	}
+	start, end := c.yearStart(year), c.yearStart(year+1)
+
+	fy := &fiscalYear{name: year}
+	if c.nameByEnd && c.startMonth != time.January {
+		fy.name++
+	}
+	for i := 0; i < 12; i++ {
+		if c.pattern == nil {
+			fy.periods = append(fy.periods, start.AddDate(0, i, 0))
+		} else {
+			weeks := 0
+			for j := 0; j < i; j++ {
+				weeks += c.pattern[j%3]
+			}
+			fy.periods = append(fy.periods, start.AddDate(0, 0, 7*weeks))
+		}
+	}
+	fy.periods = append(fy.periods, end)
+	return fy
+}
+
+func (fy *fiscalYear) period(d time.Time) int {
+	p := 0
+	for p < 11 && !fy.periods[p+1].After(d) {
+		p++
+	}
+	return p
+}
+
+// week returns the first day of the week including the day, and the number
+// of the week in the fiscal year. Weeks begin on Sunday, and the first and
+// the last week of a monthly fiscal year may be shorter than 7 days.
+func (fy *fiscalYear) week(d time.Time) (time.Time, int) {
+	start := fy.periods[0]
+	offset := int(start.Weekday())
+	n := (daysBetween(start, d) + offset) / 7
+	weekStart := start.AddDate(0, 0, 7*n-offset)
+	if weekStart.Before(start) {
+		weekStart = start
+	}
+	return weekStart, n + 1
+}
+
+func encapFiscal(t time.Time) map[string]interface{} {
+	d := date(t.Date())
+	fy := currentFiscalCalendar().yearOf(d)
+	p := fy.period(d)
+	_, week := fy.week(d)
+	return map[string]interface{}{
+		"year":      fy.name,
+		"quarter":   p/3 + 1,
+		"period":    p + 1,
+		"week":      week,
+		"dayOfYear": daysBetween(fy.periods[0], d) + 1,
+	}
+}
+
+// fiscalRange returns the first day of the unit including the day and that
+// of the next one.
+func fiscalRange(d time.Time, unit string) (time.Time, time.Time, error) {
+	fy := currentFiscalCalendar().yearOf(d)
+	switch unit {
+	case "year":
+		return fy.periods[0], fy.periods[12], nil
+	case "quarter":
+		q := fy.period(d) / 3
+		return fy.periods[3*q], fy.periods[3*q+3], nil
+	case "period":
+		p := fy.period(d)
+		return fy.periods[p], fy.periods[p+1], nil
+	case "week":
+		start, _ := fy.week(d)
+		end := start.AddDate(0, 0, 7-int(start.Weekday()))
+		if end.After(fy.periods[12]) {
+			end = fy.periods[12]
+		}
+		return start, end, nil
+	}
+	return time.Time{}, time.Time{}, errors.Errorf("unknown unit: %s (expected year, quarter, period or week)", unit)
+}
+
+func fiscalBoundary(v interface{}, args []interface{}, end bool) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	unit, ok := args[0].(string)
+	if !ok {
+		return errors.Errorf("expected string as unit, but found unexpected type: %T", args[0])
+	}
+	start, next, err := fiscalRange(date(t.Date()), unit)
+	if err != nil {
+		return err
+	}
+	if !end {
+		return encapLocalTime(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, t.Location())
+	}
+	u, _, err := localTime(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, t.Location())
+	if err != nil {
+		return err
+	}
+	return EncapTime(u.Add(-1))
+}
+
+// StartOfFiscal returns the beginning of the fiscal year, quarter, period or
+// week including the time.
+func StartOfFiscal(v interface{}, args []interface{}) interface{} {
+	return fiscalBoundary(v, args, false)
+}
+
+// EndOfFiscal returns the last instant (in nanoseconds) of the fiscal year,
+// quarter, period or week including the time.
+func EndOfFiscal(v interface{}, args []interface{}) interface{} {
+	return fiscalBoundary(v, args, true)
+}
@@ -0,0 +1,411 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// namedFormat is a format of time which a single layout of Go cannot
+// express, e.g. because of optional parts or obsolete variants.
+type namedFormat struct {
+	name   string
+	parse  func(s string, loc *time.Location) (time.Time, string, error)
+	format func(t time.Time) (string, error)
+	// guess restricts the strings which guess tries to parse in the format,
+	// or nil if guess does not try the format
+	guess *regexp.Regexp
+}
+
+var anything = regexp.MustCompile(``)
+
+// namedFormats are the named formats in the order which guess tries them,
+// after supportedKnownTimeFormats.
+var namedFormats = []*namedFormat{
+	{"rfc2822", parseRFC2822, formatRFC2822, anything},
+	{"httpdate", parseHTTPDate, formatHTTPDate, anything},
+	{"utctime", parseUTCTime, formatUTCTime, regexp.MustCompile(`^\d{10}(\d{2})?(Z|[+-]\d{4})$`)},
+	{"generalizedtime", parseGeneralizedTime, formatGeneralizedTime, regexp.MustCompile(`^\d{14}([.,]\d+)?(Z|[+-]\d{4})$`)},
+	{"sql", parseSQL, formatSQL, regexp.MustCompile(`^\d{4}-\d{2}-\d{2} \d`)},
+	{"gitraw", parseGitRaw, formatGitRaw, anything},
+	{"w3c", parseW3C, formatW3C, regexp.MustCompile(`T`)},
+	{"atom", parseAtom, formatAtom, nil},
+}
+
+func getNamedFormat(name string) *namedFormat {
+	for _, f := range namedFormats {
+		if f.name == name {
+			return f
+		}
+	}
+	panic("unknown format: " + name)
+}
+
+// FromNamedFormat returns the function which parses strings in the format.
+// Times without zones are interpreted in the zone specified by --assume-tz.
+func FromNamedFormat(name string) BuiltinFn {
+	f := getNamedFormat(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		s, ok := getStringArg(v, args)
+		if !ok {
+			return errors.Errorf("unexpected type: %T", v)
+		}
+		t, status, err := f.parse(s, assumedLocation)
+		if err != nil {
+			return err
+		}
+		return EncapLocalTime(t, status)
+	}
+}
+
+// ToNamedFormat returns the function which formats times in the format.
+func ToNamedFormat(name string) BuiltinFn {
+	f := getNamedFormat(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		t, ok := getTimeArg(v, args)
+		if !ok {
+			return errors.Errorf("unexpected type: %T", v)
+		}
+		s, err := f.format(*t)
+		if err != nil {
+			return err
+		}
+		return s
+	}
+}
+
+// guessNamedFormats tries the named formats in order for guess, and returns
+// the time, its status and the name of the format which matched.
+func guessNamedFormats(s string, loc *time.Location) (time.Time, string, string, bool) {
+	for _, f := range namedFormats {
+		if f.guess == nil || !f.guess.MatchString(s) {
+			continue
+		}
+		if t, status, err := f.parse(s, loc); err == nil {
+			return t, status, f.name, true
+		}
+	}
+	return time.Time{}, "", "", false
+}
+
+func errUnableToParseAs(format, s string) error {
+	return errors.Errorf("unable to parse as %s: %s", format, s)
+}
+
+// inOffset returns the time at the offset. It is in the local time zone if
+// the offset is the same as that of the local time zone at that time, as
+// time.Parse does.
+func inOffset(t time.Time, offset int) time.Time {
+	if _, o := t.In(time.Local).Zone(); o == offset {
+		return t.In(time.Local)
+	}
+	return t.In(time.FixedZone("", offset))
+}
+
+// withOffset returns the time of the wall clock (in UTC) at the offset, or
+// in UTC if utc is true (e.g. for GMT).
+func withOffset(wall time.Time, offset int, utc bool) time.Time {
+	if utc {
+		return wall
+	}
+	return inOffset(wall.Add(-time.Duration(offset)*time.Second), offset)
+}
+
+// parseOffset parses numeric offsets, i.e. +hh, +hhmm and +hh:mm.
+func parseOffset(s string) (int, bool) {
+	s = strings.Replace(s, ":", "", 1)
+	if len(s) != 3 && len(s) != 5 || (s[0] != '+' && s[0] != '-') {
+		return 0, false
+	}
+	h, err := strconv.Atoi(s[1:3])
+	if err != nil {
+		return 0, false
+	}
+	m := 0
+	if len(s) == 5 {
+		if m, err = strconv.Atoi(s[3:]); err != nil || m >= 60 {
+			return 0, false
+		}
+	}
+	offset := h*3600 + m*60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return offset, true
+}
+
+// parseFraction parses the digits after the decimal point as nanoseconds.
+func parseFraction(s string) int {
+	if len(s) > 9 {
+		s = s[:9]
+	}
+	ns, _ := strconv.Atoi(s + strings.Repeat("0", 9-len(s)))
+	return ns
+}
+
+// dateTime builds the wall clock in UTC from the fields, or reports false if
+// any of them is out of range.
+func dateTime(year int, month time.Month, day, hour, min, sec, nsec int) (time.Time, bool) {
+	t := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+	y, mo, d := t.Date()
+	ok := y == year && mo == month && d == day && t.Hour() == hour && t.Minute() == min && t.Second() == sec
+	return t, ok
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// RFC 2822 (and RFC 5322), the format of the Date header of emails
+
+var (
+	reRFC2822        = regexp.MustCompile(`^(?:([A-Za-z]{3})\s*,\s*)?(\d{1,2})\s+([A-Za-z]{3})\s+(\d{2,4})\s+(\d{2})\s*:\s*(\d{2})(?:\s*:\s*(\d{2}))?\s+([+-]\d{4}|[A-Za-z]{1,5})$`)
+	reRFC2822Comment = regexp.MustCompile(`\([^()]*\)`)
+)
+
+// rfc2822Zones are the obsolete zones of RFC 2822. Military zones (single
+// letters) are treated as -0000 as RFC 2822 recommends.
+var rfc2822Zones = map[string]int{
+	"UT": 0, "GMT": 0,
+	"EST": -5 * 3600, "EDT": -4 * 3600,
+	"CST": -6 * 3600, "CDT": -5 * 3600,
+	"MST": -7 * 3600, "MDT": -6 * 3600,
+	"PST": -8 * 3600, "PDT": -7 * 3600,
+}
+
+func parseMonthAbbr(s string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		if strings.EqualFold(m.String()[:3], s) {
+			return m, true
+		}
+	}
+	return 0, false
+}
+
+func parseRFC2822(s string, _ *time.Location) (time.Time, string, error) {
+	// remove comments (which may be nested) and fold white spaces
+	v := s
+	for reRFC2822Comment.MatchString(v) {
+		v = reRFC2822Comment.ReplaceAllString(v, " ")
+	}
+	v = strings.TrimSpace(v)
+	m := reRFC2822.FindStringSubmatch(v)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("RFC 2822", s)
+	}
+	month, ok := parseMonthAbbr(m[3])
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("RFC 2822", s)
+	}
+	year := atoi(m[4])
+	switch len(m[4]) {
+	case 2:
+		year += 1900
+		if year < 1950 {
+			year += 100
+		}
+	case 3:
+		year += 1900
+	}
+	wall, ok := dateTime(year, month, atoi(m[2]), atoi(m[5]), atoi(m[6]), atoi(m[7]), 0)
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("RFC 2822", s)
+	}
+
+	zone := strings.ToUpper(m[8])
+	if offset, ok := parseOffset(zone); ok {
+		return withOffset(wall, offset, false), "", nil
+	}
+	if offset, ok := rfc2822Zones[zone]; ok {
+		return withOffset(wall, offset, offset == 0), "", nil
+	}
+	if len(zone) == 1 && zone != "J" {
+		return wall, "", nil
+	}
+	return time.Time{}, "", errors.Errorf("unknown time zone abbreviation: %s", m[8])
+}
+
+func formatRFC2822(t time.Time) (string, error) {
+	return t.Format(time.RFC1123Z), nil
+}
+
+// HTTP-date of RFC 9110, i.e. IMF-fixdate and the obsolete RFC 850 and
+// asctime formats, which are always in GMT
+
+const imfFixdate = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+var httpDateLayouts = []string{
+	imfFixdate,
+	"Monday, 02-Jan-06 15:04:05 GMT",
+	"Mon Jan _2 15:04:05 2006",
+}
+
+func parseHTTPDate(s string, _ *time.Location) (time.Time, string, error) {
+	for _, layout := range httpDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, "", nil
+		}
+	}
+	return time.Time{}, "", errUnableToParseAs("HTTP-date", s)
+}
+
+func formatHTTPDate(t time.Time) (string, error) {
+	return t.UTC().Format(imfFixdate), nil
+}
+
+// UTCTime and GeneralizedTime of ASN.1 (X.680), e.g. in X.509 certificates
+
+var (
+	reUTCTime         = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})?(Z|[+-]\d{4})$`)
+	reGeneralizedTime = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})(\d{2})(\d{2})(\d{2})(?:[.,](\d+))?(Z|[+-]\d{4})?$`)
+)
+
+// zoned returns the time of the wall clock in the zone, i.e. Z, a numeric
+// offset or, if empty, the location.
+func zoned(wall time.Time, zone string, loc *time.Location) (time.Time, string, error) {
+	switch zone {
+	case "Z":
+		return wall, "", nil
+	case "":
+		return localTime(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	}
+	offset, ok := parseOffset(zone)
+	if !ok {
+		return time.Time{}, "", errors.Errorf("invalid offset: %s", zone)
+	}
+	return withOffset(wall, offset, false), "", nil
+}
+
+func parseUTCTime(s string, loc *time.Location) (time.Time, string, error) {
+	m := reUTCTime.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("UTCTime", s)
+	}
+	// as RFC 5280 specifies
+	year := 1900 + atoi(m[1])
+	if year < 1950 {
+		year += 100
+	}
+	wall, ok := dateTime(year, time.Month(atoi(m[2])), atoi(m[3]), atoi(m[4]), atoi(m[5]), atoi(m[6]), 0)
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("UTCTime", s)
+	}
+	return zoned(wall, m[7], loc)
+}
+
+func formatUTCTime(t time.Time) (string, error) {
+	t = t.UTC()
+	if t.Year() < 1950 || t.Year() > 2049 {
+		return "", errors.Errorf("out of range of UTCTime (1950 to 2049): %s", t.Format(time.RFC3339))
+	}
+	return t.Format("060102150405Z"), nil
+}
+
+func parseGeneralizedTime(s string, loc *time.Location) (time.Time, string, error) {
+	m := reGeneralizedTime.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("GeneralizedTime", s)
+	}
+	wall, ok := dateTime(atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3]), atoi(m[4]), atoi(m[5]), atoi(m[6]), parseFraction(m[7]))
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("GeneralizedTime", s)
+	}
+	return zoned(wall, m[8], loc)
+}
+
+// formatGeneralizedTime formats the time in UTC, without trailing zeros of
+// the fraction as DER requires.
+func formatGeneralizedTime(t time.Time) (string, error) {
+	return t.UTC().Format("20060102150405.999999999Z"), nil
+}
+
+// SQL timestamps, e.g. 2006-01-02 15:04:05.999999 of DATETIME of MySQL and
+// timestamp of PostgreSQL, optionally with offsets as timestamptz of
+// PostgreSQL has
+
+var reSQL = regexp.MustCompile(`^(\d{4})-(\d{2})-(\d{2})(?:[ T](\d{2}):(\d{2}):(\d{2})(?:\.(\d{1,9}))?\s*(Z|[+-]\d{2}(?::?\d{2})?)?)?$`)
+
+func parseSQL(s string, loc *time.Location) (time.Time, string, error) {
+	m := reSQL.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("SQL timestamp", s)
+	}
+	wall, ok := dateTime(atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3]), atoi(m[4]), atoi(m[5]), atoi(m[6]), parseFraction(m[7]))
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("SQL timestamp", s)
+	}
+	return zoned(wall, m[8], loc)
+}
+
+func formatSQL(t time.Time) (string, error) {
+	return t.Format("2006-01-02 15:04:05.999999"), nil
+}
+
+// the raw format of dates of git, i.e. Unix time and the offset
+
+var reGitRaw = regexp.MustCompile(`^@?(-?\d+) ([+-]\d{4})$`)
+
+func parseGitRaw(s string, _ *time.Location) (time.Time, string, error) {
+	m := reGitRaw.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("git raw date", s)
+	}
+	sec, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, "", errUnableToParseAs("git raw date", s)
+	}
+	offset, ok := parseOffset(m[2])
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("git raw date", s)
+	}
+	return inOffset(time.Unix(sec, 0), offset), "", nil
+}
+
+func formatGitRaw(t time.Time) (string, error) {
+	return fmt.Sprintf("%d %s", t.Unix(), t.Format("-0700")), nil
+}
+
+// W3C date and time formats (W3C-DTF), which allow reduced precision e.g.
+// 2006 and 2006-01, and Atom (RFC 4287) dates, which are RFC 3339 date-times
+
+var reW3C = regexp.MustCompile(`^(\d{4})(?:-(\d{2})(?:-(\d{2})(?:T(\d{2}):(\d{2})(?::(\d{2})(?:\.(\d+))?)?(Z|[+-]\d{2}:\d{2}))?)?)?$`)
+
+func parseW3C(s string, loc *time.Location) (time.Time, string, error) {
+	m := reW3C.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, "", errUnableToParseAs("W3C date", s)
+	}
+	month, day := 1, 1
+	if m[2] != "" {
+		month = atoi(m[2])
+	}
+	if m[3] != "" {
+		day = atoi(m[3])
+	}
+	wall, ok := dateTime(atoi(m[1]), time.Month(month), day, atoi(m[4]), atoi(m[5]), atoi(m[6]), parseFraction(m[7]))
+	if !ok {
+		return time.Time{}, "", errUnableToParseAs("W3C date", s)
+	}
+	return zoned(wall, m[8], loc)
+}
+
+func formatW3C(t time.Time) (string, error) {
+	return t.Format(time.RFC3339), nil
+}
+
+func parseAtom(s string, _ *time.Location) (time.Time, string, error) {
+	t, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+	if err != nil {
+		return time.Time{}, "", errUnableToParseAs("Atom date", s)
+	}
+	return t, "", nil
+}
+
+func formatAtom(t time.Time) (string, error) {
+	return t.Format(time.RFC3339Nano), nil
+}
@@ -0,0 +1,264 @@
+package builtin
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// humanizeUnit is a unit used in human-friendly representations.
+type humanizeUnit int
+
+const (
+	unitYear humanizeUnit = iota
+	unitMonth
+	unitDay
+	unitHour
+	unitMinute
+	unitSecond
+	unitMillisecond
+)
+
+// approximateUnits are the units of approximate representations, and their
+// (approximate) lengths.
+var approximateUnits = []struct {
+	unit humanizeUnit
+	d    time.Duration
+}{
+	{unitYear, 365 * 24 * time.Hour},
+	{unitMonth, 30 * 24 * time.Hour},
+	{unitDay, 24 * time.Hour},
+	{unitHour, time.Hour},
+	{unitMinute, time.Minute},
+	{unitSecond, time.Second},
+}
+
+// exactUnits are the units of the short and the long representations.
+var exactUnits = []struct {
+	unit humanizeUnit
+	d    time.Duration
+}{
+	{unitDay, 24 * time.Hour},
+	{unitHour, time.Hour},
+	{unitMinute, time.Minute},
+	{unitSecond, time.Second},
+	{unitMillisecond, time.Millisecond},
+}
+
+// language is a language pack for humanize.
+type language struct {
+	// long returns e.g. "2 hours", and short returns e.g. "2h".
+	long, short func(n int, u humanizeUnit) string
+	// separators between units in the long and the short representations.
+	longSeparator, shortSeparator string
+	about                         func(s string) string
+	lessThanASecond               string
+	ago, later                    func(s string) string
+	justNow                       string
+}
+
+var english = &language{
+	long: func(n int, u humanizeUnit) string {
+		name := [...]string{"year", "month", "day", "hour", "minute", "second", "millisecond"}[u]
+		if n != 1 {
+			name += "s"
+		}
+		return fmt.Sprintf("%d %s", n, name)
+	},
+	short: func(n int, u humanizeUnit) string {
+		return fmt.Sprintf("%d%s", n, [...]string{"y", "mo", "d", "h", "m", "s", "ms"}[u])
+	},
+	longSeparator:   ", ",
+	shortSeparator:  " ",
+	about:           func(s string) string { return "about " + s },
+	lessThanASecond: "less than a second",
+	ago:             func(s string) string { return s + " ago" },
+	later:           func(s string) string { return "in " + s },
+	justNow:         "just now",
+}
+
+var japanese = &language{
+	long: func(n int, u humanizeUnit) string {
+		return fmt.Sprintf("%d%s", n, [...]string{"年", "か月", "日", "時間", "分", "秒", "ミリ秒"}[u])
+	},
+	short: func(n int, u humanizeUnit) string {
+		return fmt.Sprintf("%d%s", n, [...]string{"年", "か月", "日", "時間", "分", "秒", "ミリ秒"}[u])
+	},
+	longSeparator:   "",
+	shortSeparator:  "",
+	about:           func(s string) string { return "約" + s },
+	lessThanASecond: "1秒未満",
+	ago:             func(s string) string { return s + "前" },
+	later:           func(s string) string { return s + "後" },
+	justNow:         "たった今",
+}
+
+// languages are the available language packs. Add a language pack here to
+// support another language.
+var languages = map[string]*language{
+	"en": english,
+	"ja": japanese,
+}
+
+type humanizeOptions struct {
+	lang      *language
+	style     string
+	precision int
+}
+
+func getHumanizeOptions(v interface{}) (*humanizeOptions, error) {
+	opts := &humanizeOptions{lang: english, style: "approximate"}
+	if lang, ok := languages[humanizeLocale]; ok {
+		opts.lang = lang
+	}
+	if v == nil {
+		return opts, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("expected object as options, but found unexpected type: %T", v)
+	}
+	if x, ok := m["lang"]; ok {
+		name, _ := x.(string)
+		lang, ok := languages[strings.ToLower(name)]
+		if !ok {
+			return nil, errors.Errorf("unsupported language: %v", x)
+		}
+		opts.lang = lang
+	}
+	if x, ok := m["style"]; ok {
+		switch x {
+		case "approximate", "short", "long":
+			opts.style = x.(string)
+		default:
+			return nil, errors.Errorf("unknown style: %v (expected approximate, short or long)", x)
+		}
+	}
+	if x, ok := m["precision"]; ok {
+		n, ok := x.(int)
+		if !ok || n < 1 {
+			return nil, errors.Errorf("expected positive integer as precision, but found: %v", x)
+		}
+		opts.precision = n
+	}
+	return opts, nil
+}
+
+// humanizeDuration returns a human-friendly representation of the absolute
+// value of the duration. exact tells whether the representation is exact,
+// which matters to the approximate style only.
+func humanizeDuration(d time.Duration, opts *humanizeOptions) (s string, exact bool) {
+	// the magnitude as uint64 not to overflow for math.MinInt64
+	m := uint64(d)
+	if d < 0 {
+		m = -m
+	}
+	lang := opts.lang
+
+	if opts.style == "approximate" {
+		if m == 0 {
+			return lang.long(0, unitSecond), true
+		}
+		if m < uint64(time.Second) {
+			// already tells it is approximate
+			return lang.lessThanASecond, true
+		}
+		for i, u := range approximateUnits {
+			if m < uint64(u.d) {
+				continue
+			}
+			n := int(math.Round(float64(m) / float64(u.d)))
+			unit := u.unit
+			if i > 0 && uint64(n)*uint64(u.d) >= uint64(approximateUnits[i-1].d) {
+				n, unit = 1, approximateUnits[i-1].unit
+				exact = m == uint64(approximateUnits[i-1].d)
+			} else {
+				exact = uint64(n)*uint64(u.d) == m
+			}
+			return lang.long(n, unit), exact
+		}
+	}
+
+	format, separator := lang.long, lang.longSeparator
+	if opts.style == "short" {
+		format, separator = lang.short, lang.shortSeparator
+	}
+	var parts []string
+	shown := 0
+	for _, u := range exactUnits {
+		n := int(m / uint64(u.d))
+		m -= uint64(n) * uint64(u.d)
+		if n == 0 && shown == 0 {
+			continue
+		}
+		shown++
+		if n != 0 {
+			parts = append(parts, format(n, u.unit))
+		}
+		if opts.precision > 0 && shown >= opts.precision {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return format(0, unitSecond), true
+	}
+	return strings.Join(parts, separator), true
+}
+
+// Humanize returns a human-friendly representation of a duration (e.g.
+// "about 2 hours"), or of a time relative to now or to the time given as an
+// argument (e.g. "3 hours ago"). Options can be given as the last argument.
+func Humanize(v interface{}, args []interface{}) interface{} {
+	var ref *time.Time
+	var optsArg interface{}
+	switch len(args) {
+	case 1:
+		if t, ok := DecapTime(args[0]); ok {
+			ref = t
+		} else {
+			optsArg = args[0]
+		}
+	case 2:
+		t, ok := DecapTime(args[0])
+		if !ok {
+			return errors.Errorf("expected time as the first argument, but found unexpected type: %T", args[0])
+		}
+		ref, optsArg = t, args[1]
+	}
+	opts, err := getHumanizeOptions(optsArg)
+	if err != nil {
+		return err
+	}
+
+	if d, ok := DecapDuration(v); ok {
+		s, exact := humanizeDuration(*d, opts)
+		if !exact {
+			s = opts.lang.about(s)
+		}
+		if *d < 0 {
+			s = "-" + s
+		}
+		return s
+	}
+
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time or duration as input, but found unexpected type: %T", v)
+	}
+	if ref == nil {
+		now := time.Now()
+		ref = &now
+	}
+	d := t.Sub(*ref)
+	if opts.style == "approximate" && d > -time.Second && d < time.Second {
+		return opts.lang.justNow
+	}
+	s, _ := humanizeDuration(d, opts)
+	if d < 0 {
+		return opts.lang.ago(s)
+	}
+	return opts.lang.later(s)
+}
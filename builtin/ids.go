@@ -0,0 +1,419 @@
+package builtin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"math/big"
+	mathrand "math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// idKind is a kind of identifiers which embed their creation time.
+type idKind struct {
+	name string
+	// parse returns the time embedded in the identifier, and the name of
+	// the kind in detail (e.g. uuidv7) for guess_explain
+	parse func(s string) (time.Time, string, error)
+	// guess restricts the strings which guess tries to parse as the kind
+	guess *regexp.Regexp
+	// generate returns a new identifier of the kind (version 7 for UUIDs)
+	// which embeds the time
+	generate func(t time.Time) (string, error)
+}
+
+// idKinds are the kinds of identifiers in the order which guess tries them,
+// after the formats of time. Snowflake IDs are not guessed, as they are
+// indistinguishable from other integers.
+var idKinds = []*idKind{
+	{"uuid", parseUUID, regexp.MustCompile(`^[0-9A-Fa-f]{8}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{4}-[0-9A-Fa-f]{12}$`), generateUUIDv7},
+	{"ulid", parseULID, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`), generateULID},
+	{"ksuid", parseKSUID, regexp.MustCompile(`^[0-9A-Za-z]{27}$`), generateKSUID},
+	{"objectid", parseObjectID, regexp.MustCompile(`^[0-9A-Fa-f]{24}$`), generateObjectID},
+}
+
+// plausibleSince are the Unix times from which guess accepts the
+// identifiers of the kinds (in detail, e.g. uuidv7), until a year from now.
+// Since e.g. any 26 digits are ULIDs, and any 27 alphanumerics and 24
+// hexadecimal digits are KSUIDs and ObjectIds, those of the times before the
+// formats came out or far in the future are not guessed.
+var plausibleSince = map[string]int64{
+	"uuidv7":   epochUUIDv7,
+	"ulid":     epochULID,
+	"ksuid":    epochKSUID,
+	"objectid": epochObjectID,
+}
+
+// the epochs of identifiers in Unix time
+const (
+	epochUUID  = -12219292800 // 1582-10-15T00:00:00Z, of UUID version 1 and 6
+	epochKSUID = 1400000000   // 2014-05-13T16:53:20Z
+	// epochObjectID is 2009-01-01T00:00:00Z, which is not the epoch of
+	// ObjectIds but before the first release of MongoDB
+	epochObjectID = 1230768000
+	// epochULID and epochUUIDv7 are 2016-01-01T00:00:00Z and
+	// 2020-01-01T00:00:00Z, before the specification of ULID and the first
+	// draft of UUID version 7 came out
+	epochULID   = 1451606400
+	epochUUIDv7 = 1577836800
+)
+
+// snowflakeEpochs are the epochs of well-known Snowflake IDs in Unix time in
+// milliseconds.
+var snowflakeEpochs = map[string]int64{
+	"twitter": 1288834974657, // 2010-11-04T01:42:54.657Z
+	"discord": 1420070400000, // 2015-01-01T00:00:00Z
+}
+
+func getIDKind(name string) *idKind {
+	for _, k := range idKinds {
+		if k.name == name {
+			return k
+		}
+	}
+	panic("unknown identifier kind: " + name)
+}
+
+// FromID returns the function which extracts the creation time from
+// identifiers of the kind.
+func FromID(name string) BuiltinFn {
+	k := getIDKind(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		s, ok := getStringArg(v, args)
+		if !ok {
+			return errors.Errorf("expected string as input, but found unexpected type: %T", v)
+		}
+		t, _, err := k.parse(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		return EncapTime(t)
+	}
+}
+
+// ToID returns the function which generates identifiers of the kind which
+// embed the time.
+func ToID(name string) BuiltinFn {
+	k := getIDKind(name)
+	return func(v interface{}, args []interface{}) interface{} {
+		t, ok := getTimeArg(v, args)
+		if !ok {
+			return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+		}
+		id, err := k.generate(*t)
+		if err != nil {
+			return err
+		}
+		return id
+	}
+}
+
+// idRandom is the source of the random parts of generated identifiers.
+var idRandom io.Reader = rand.Reader
+
+// SetSeed makes the random parts of generated identifiers deterministic, by
+// a pseudo-random source seeded by the seed instead of crypto/rand.
+func SetSeed(seed int64) {
+	idRandom = mathrand.New(mathrand.NewSource(seed))
+	objectIDProcess = nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(idRandom, b); err != nil {
+		return nil, errors.Wrap(err, "unable to generate random bytes")
+	}
+	return b, nil
+}
+
+// putUint48 puts the lowest 48 bits of the integer in big endian.
+func putUint48(b []byte, n uint64) {
+	for i := 0; i < 6; i++ {
+		b[i] = byte(n >> (8 * (5 - i)))
+	}
+}
+
+// unixMilli48 returns Unix time in milliseconds of the time, which must fit
+// in 48 bits for ULIDs and UUIDv7.
+func unixMilli48(t time.Time, kind string) (uint64, error) {
+	ms := t.UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return 0, errors.Errorf("out of range of %s: %s", kind, t.Format(time.RFC3339Nano))
+	}
+	return uint64(ms), nil
+}
+
+// guessIDs tries the kinds of identifiers in order for guess, and returns
+// the time and the name of the kind which matched.
+func guessIDs(s string) (time.Time, string, bool) {
+	for _, k := range idKinds {
+		if !k.guess.MatchString(s) {
+			continue
+		}
+		if t, kind, err := k.parse(s); err == nil && isPlausible(t, kind) {
+			return t, kind, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// isPlausible reports whether the time embedded in an identifier of the
+// kind is likely to be its creation time, for guess.
+func isPlausible(t time.Time, kind string) bool {
+	since, ok := plausibleSince[kind]
+	if !ok {
+		return true
+	}
+	return t.Unix() >= since && t.Before(time.Now().AddDate(1, 0, 0))
+}
+
+// LooksLikeID reports whether guess recognizes the string as an identifier
+// rather than a time.
+func LooksLikeID(s string) bool {
+	_, _, ok := guessIDs(s)
+	return ok
+}
+
+var reUUID = regexp.MustCompile(`^(?i)(?:urn:uuid:)?(\{)?([0-9a-f]{8})(-?)([0-9a-f]{4})(-?)([0-9a-f]{4})(-?)([0-9a-f]{4})(-?)([0-9a-f]{12})(\})?$`)
+
+// parseUUID parses UUIDs in the canonical form, with or without hyphens,
+// braces or the URN prefix, and extracts the time from those of version 1
+// (100-nanosecond intervals since 1582-10-15 in the fields of the low bits
+// first), 6 (in the order of the high bits first) and 7 (Unix time in
+// milliseconds).
+func parseUUID(s string) (time.Time, string, error) {
+	m := reUUID.FindStringSubmatch(s)
+	if m == nil || (m[1] == "") != (m[11] == "") || !(m[3] == m[5] && m[5] == m[7] && m[7] == m[9]) {
+		return time.Time{}, "", errUnableToParseAs("UUID", s)
+	}
+	b, _ := hex.DecodeString(m[2] + m[4] + m[6] + m[8] + m[10])
+	if b[8]&0xc0 != 0x80 {
+		return time.Time{}, "", errors.Errorf("not a UUID of the variant of RFC 4122: %s", s)
+	}
+
+	version := int(b[6] >> 4)
+	kind := "uuidv" + strconv.Itoa(version)
+	switch version {
+	case 1, 6:
+		low := uint64(b[0])<<24 | uint64(b[1])<<16 | uint64(b[2])<<8 | uint64(b[3])
+		mid := uint64(b[4])<<8 | uint64(b[5])
+		high := uint64(b[6]&0x0f)<<8 | uint64(b[7])
+		ticks := high<<48 | mid<<32 | low
+		if version == 6 {
+			ticks = low<<28 | mid<<12 | high
+		}
+		t, _, err := fromTicks(epochUUID, 100)(0, int64(ticks), true)
+		return t, kind, err
+	case 7:
+		ms := uint64(b[0])<<40 | uint64(b[1])<<32 | uint64(b[2])<<24 | uint64(b[3])<<16 | uint64(b[4])<<8 | uint64(b[5])
+		return time.UnixMilli(int64(ms)), kind, nil
+	}
+	return time.Time{}, "", errors.Errorf("UUID version %d has no timestamp: %s", version, s)
+}
+
+// generateUUIDv7 generates UUIDs of version 7 in the canonical form, which
+// have 74 random bits after Unix time in milliseconds.
+func generateUUIDv7(t time.Time) (string, error) {
+	ms, err := unixMilli48(t, "UUIDv7")
+	if err != nil {
+		return "", err
+	}
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	putUint48(b, ms)
+	b[6] = 0x70 | b[6]&0x0f
+	b[8] = 0x80 | b[8]&0x3f
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
+
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// parseULID extracts Unix time in milliseconds from the first 10 characters
+// of ULIDs, which are case insensitive.
+func parseULID(s string) (time.Time, string, error) {
+	if len(s) != 26 {
+		return time.Time{}, "", errUnableToParseAs("ULID", s)
+	}
+	var ms int64
+	for i, c := range strings.ToUpper(s) {
+		n := strings.IndexRune(crockfordBase32, c)
+		if n < 0 || (i == 0 && n > 7) {
+			return time.Time{}, "", errUnableToParseAs("ULID", s)
+		}
+		if i < 10 {
+			ms = ms<<5 | int64(n)
+		}
+	}
+	return time.UnixMilli(ms), "ulid", nil
+}
+
+// generateULID generates ULIDs, which have 80 random bits after Unix time in
+// milliseconds.
+func generateULID(t time.Time) (string, error) {
+	ms, err := unixMilli48(t, "ULID")
+	if err != nil {
+		return "", err
+	}
+	b, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	putUint48(b, ms)
+	// 128 bits in 26 characters of 5 bits, from the lowest bits
+	n := new(big.Int).SetBytes(b)
+	id := make([]byte, 26)
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = crockfordBase32[new(big.Int).And(n, big.NewInt(31)).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(id), nil
+}
+
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxKSUID is the largest value of KSUIDs, i.e. 20 bytes of 0xff.
+var maxKSUID = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+// parseKSUID extracts the seconds since the epoch of KSUIDs from the first 4
+// bytes of 27 characters in base62.
+func parseKSUID(s string) (time.Time, string, error) {
+	if len(s) != 27 {
+		return time.Time{}, "", errUnableToParseAs("KSUID", s)
+	}
+	n := new(big.Int)
+	for _, c := range s {
+		d := strings.IndexRune(base62, c)
+		if d < 0 {
+			return time.Time{}, "", errUnableToParseAs("KSUID", s)
+		}
+		n.Mul(n, big.NewInt(62)).Add(n, big.NewInt(int64(d)))
+	}
+	if n.Cmp(maxKSUID) > 0 {
+		return time.Time{}, "", errUnableToParseAs("KSUID", s)
+	}
+	sec := new(big.Int).Rsh(n, 128).Int64()
+	return time.Unix(sec+epochKSUID, 0), "ksuid", nil
+}
+
+// generateKSUID generates KSUIDs, which have 16 random bytes after seconds
+// since the epoch.
+func generateKSUID(t time.Time) (string, error) {
+	sec := t.Unix() - epochKSUID
+	if sec < 0 || sec >= 1<<32 {
+		return "", errors.Errorf("out of range of KSUID: %s", t.Format(time.RFC3339))
+	}
+	b, err := randomBytes(20)
+	if err != nil {
+		return "", err
+	}
+	b[0], b[1], b[2], b[3] = byte(sec>>24), byte(sec>>16), byte(sec>>8), byte(sec)
+	// big.Int.Text uses lower case letters before upper case ones
+	n, d := new(big.Int).SetBytes(b), new(big.Int)
+	id := make([]byte, 27)
+	for i := len(id) - 1; i >= 0; i-- {
+		n.QuoRem(n, big.NewInt(62), d)
+		id[i] = base62[d.Int64()]
+	}
+	return string(id), nil
+}
+
+// parseObjectID extracts Unix time in seconds from the first 4 bytes of
+// ObjectIds of MongoDB.
+func parseObjectID(s string) (time.Time, string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 12 {
+		return time.Time{}, "", errUnableToParseAs("ObjectId", s)
+	}
+	sec := int64(b[0])<<24 | int64(b[1])<<16 | int64(b[2])<<8 | int64(b[3])
+	return time.Unix(sec, 0), "objectid", nil
+}
+
+// objectIDProcess is the random value unique to the process and the counter
+// of ObjectIds, which are initialized on the first use.
+var objectIDProcess *struct {
+	random  []byte
+	counter uint32
+}
+
+// generateObjectID generates ObjectIds, which have the random value unique
+// to the process and the counter incremented on each generation after Unix
+// time in seconds.
+func generateObjectID(t time.Time) (string, error) {
+	sec := t.Unix()
+	if sec < 0 || sec >= 1<<32 {
+		return "", errors.Errorf("out of range of ObjectId: %s", t.Format(time.RFC3339))
+	}
+	if objectIDProcess == nil {
+		b, err := randomBytes(8)
+		if err != nil {
+			return "", err
+		}
+		objectIDProcess = &struct {
+			random  []byte
+			counter uint32
+		}{b[:5], uint32(b[5])<<16 | uint32(b[6])<<8 | uint32(b[7])}
+	}
+	p := objectIDProcess
+	c := p.counter
+	p.counter = (p.counter + 1) & 0xffffff
+
+	b := []byte{byte(sec >> 24), byte(sec >> 16), byte(sec >> 8), byte(sec)}
+	b = append(b, p.random...)
+	b = append(b, byte(c>>16), byte(c>>8), byte(c))
+	return hex.EncodeToString(b), nil
+}
+
+func getSnowflakeEpoch(v interface{}) (int64, error) {
+	switch x := v.(type) {
+	case string:
+		if ms, ok := snowflakeEpochs[strings.ToLower(x)]; ok {
+			return ms, nil
+		}
+		return 0, errors.Errorf("unknown Snowflake epoch: %s (expected twitter or discord)", x)
+	case int:
+		return int64(x), nil
+	}
+	if t, ok := DecapTime(v); ok {
+		return t.UnixMilli(), nil
+	}
+	return 0, errors.Errorf("expected string, integer or time as epoch, but found unexpected type: %T", v)
+}
+
+// FromSnowflake extracts the creation time from Snowflake IDs, whose bits
+// above the lowest 22 are milliseconds since the epoch, which is the name of
+// a well-known one (twitter or discord), Unix time in milliseconds or a time.
+func FromSnowflake(v interface{}, args []interface{}) interface{} {
+	var id int64
+	switch x := v.(type) {
+	case int:
+		id = int64(x)
+	case *big.Int:
+		if !x.IsInt64() {
+			return errors.Errorf("out of range of Snowflake ID: %s", x)
+		}
+		id = x.Int64()
+	case string:
+		var err error
+		if id, err = strconv.ParseInt(strings.TrimSpace(x), 10, 64); err != nil {
+			return errUnableToParseAs("Snowflake ID", x)
+		}
+	default:
+		return errors.Errorf("expected integer or string as input, but found unexpected type: %T", v)
+	}
+	if id < 0 {
+		return errors.Errorf("out of range of Snowflake ID: %d", id)
+	}
+	epoch, err := getSnowflakeEpoch(args[0])
+	if err != nil {
+		return err
+	}
+	return EncapTime(time.UnixMilli(id>>22 + epoch))
+}
@@ -0,0 +1,336 @@
+package builtin
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// leapSecond is an entry of the leap second table, i.e. TAI - UTC in seconds
+// from the time (in Unix time) on.
+type leapSecond struct {
+	unix   int64
+	offset int
+}
+
+// leapSeconds is the table of TAI - UTC since 1972, when UTC began to differ
+// from TAI by whole seconds. It is replaced by LoadLeapSeconds.
+var leapSeconds = []leapSecond{
+	{63072000, 10},   // 1972-01-01
+	{78796800, 11},   // 1972-07-01
+	{94694400, 12},   // 1973-01-01
+	{126230400, 13},  // 1974-01-01
+	{157766400, 14},  // 1975-01-01
+	{189302400, 15},  // 1976-01-01
+	{220924800, 16},  // 1977-01-01
+	{252460800, 17},  // 1978-01-01
+	{283996800, 18},  // 1979-01-01
+	{315532800, 19},  // 1980-01-01
+	{362793600, 20},  // 1981-07-01
+	{394329600, 21},  // 1982-07-01
+	{425865600, 22},  // 1983-07-01
+	{489024000, 23},  // 1985-07-01
+	{567993600, 24},  // 1988-01-01
+	{631152000, 25},  // 1990-01-01
+	{662688000, 26},  // 1991-01-01
+	{709948800, 27},  // 1992-07-01
+	{741484800, 28},  // 1993-07-01
+	{773020800, 29},  // 1994-07-01
+	{820454400, 30},  // 1996-01-01
+	{867715200, 31},  // 1997-07-01
+	{915148800, 32},  // 1999-01-01
+	{1136073600, 33}, // 2006-01-01
+	{1230768000, 34}, // 2009-01-01
+	{1341100800, 35}, // 2012-07-01
+	{1435708800, 36}, // 2015-07-01
+	{1483228800, 37}, // 2017-01-01
+}
+
+const (
+	// ntpEpoch is 1900-01-01T00:00:00Z in Unix time, the epoch of the times
+	// in leap-seconds.list
+	ntpEpoch = -2208988800
+	// gpsEpoch is 1980-01-06T00:00:00Z in Unix time, when GPS time was UTC
+	gpsEpoch = 315964800
+	// gpsTAIOffset is TAI - GPS time in seconds
+	gpsTAIOffset   = 19
+	secondsPerWeek = 7 * 24 * 60 * 60
+)
+
+// LoadLeapSeconds replaces the leap second table by the leap-seconds.list
+// file of IERS (or NIST) at the path, whose lines other than comments are
+// the times in seconds since 1900 and TAI - UTC from them.
+func LoadLeapSeconds(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "unable to open leap second table")
+	}
+	defer f.Close()
+
+	var table []leapSecond
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return errors.Errorf("invalid leap second table: %s:%d: %s", path, n, line)
+		}
+		ntp, err1 := strconv.ParseInt(fields[0], 10, 64)
+		offset, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			return errors.Errorf("invalid leap second table: %s:%d: %s", path, n, line)
+		}
+		if len(table) > 0 && ntp+ntpEpoch <= table[len(table)-1].unix {
+			return errors.Errorf("invalid leap second table: %s:%d: not in chronological order", path, n)
+		}
+		table = append(table, leapSecond{ntp + ntpEpoch, offset})
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrap(err, "unable to read leap second table")
+	}
+	if len(table) == 0 {
+		return errors.Errorf("invalid leap second table: %s: no entries", path)
+	}
+	leapSeconds = table
+	return nil
+}
+
+// leapSecondIndex returns the index of the entry in effect at the time, or
+// -1 before the table.
+func leapSecondIndex(t time.Time) int {
+	unix := t.Unix()
+	return sort.Search(len(leapSeconds), func(i int) bool {
+		return unix < leapSeconds[i].unix
+	}) - 1
+}
+
+// taiOffset returns TAI - UTC in seconds at the time. The offset at 1972 is
+// used before it, as an approximation.
+func taiOffset(t time.Time) int {
+	i := leapSecondIndex(t)
+	if i < 0 {
+		i = 0
+	}
+	return leapSeconds[i].offset
+}
+
+// leapSecondsBefore returns the number of leap seconds inserted (or deleted,
+// negatively) since 1972 before the time.
+func leapSecondsBefore(t time.Time) int {
+	i := leapSecondIndex(t)
+	if i < 0 {
+		return 0
+	}
+	return leapSeconds[i].offset - leapSeconds[0].offset
+}
+
+// toTAI returns the time whose clock in UTC is that of TAI at the time.
+func toTAI(t time.Time) time.Time {
+	return t.Add(time.Duration(taiOffset(t)) * time.Second).UTC()
+}
+
+// fromTAI returns the time at the clock of TAI, which is in UTC. Since Go
+// cannot represent 23:59:60, the clock in a leap second results in the first
+// second of the next day, as Unix time repeats it.
+func fromTAI(tai time.Time) time.Time {
+	unix := tai.Unix()
+	i := sort.Search(len(leapSeconds), func(i int) bool {
+		return unix < leapSeconds[i].unix+int64(leapSeconds[i].offset)
+	}) - 1
+	if i < 0 {
+		i = 0
+	}
+	return tai.Add(-time.Duration(leapSeconds[i].offset) * time.Second)
+}
+
+func getScaledTimeArg(v interface{}, args []interface{}) (*time.Time, error) {
+	if len(args) == 1 {
+		v = args[0]
+	}
+	if t, ok := DecapTime(v); ok {
+		return t, nil
+	}
+	if _, ok := v.(string); !ok {
+		return nil, errors.Errorf("expected time or string as input, but found unexpected type: %T", v)
+	}
+	g, _ := guess(v)
+	if err, ok := g.(error); ok {
+		return nil, err
+	}
+	t, _ := DecapTime(g)
+	return t, nil
+}
+
+// scaleTAI is the value of the scale field which marks the time objects
+// whose clock in UTC is that of TAI.
+const scaleTAI = "tai"
+
+// scaleUT1 is the value of the scale field which marks the time objects
+// whose clock in UTC is that of UT1.
+const scaleUT1 = "ut1"
+
+// checkUTC returns an error if the value is the time object marked by the
+// scale field, i.e. not in UTC.
+func checkUTC(v interface{}, args []interface{}) error {
+	if len(args) == 1 {
+		v = args[0]
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	switch m["scale"] {
+	case scaleTAI:
+		return errors.New("expected time in UTC as input, but found time in TAI")
+	case scaleUT1:
+		return errors.New("expected time in UTC as input, but found time in UT1")
+	}
+	return nil
+}
+
+// ToTAI converts the time to TAI, which is ahead of UTC by the leap seconds
+// (and 10 seconds at 1972). The result is the time object whose clock in
+// UTC is that of TAI, marked by the scale field.
+func ToTAI(v interface{}, args []interface{}) interface{} {
+	t, ok := getTimeArg(v, args)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	if err := checkUTC(v, args); err != nil {
+		return err
+	}
+	m := EncapTime(toTAI(*t))
+	m["scale"] = scaleTAI
+	return m
+}
+
+// FromTAI converts the time object (or the string which guess recognizes)
+// whose clock in UTC is that of TAI, to the time in UTC.
+func FromTAI(v interface{}, args []interface{}) interface{} {
+	tai, err := getScaledTimeArg(v, args)
+	if err != nil {
+		return err
+	}
+	return EncapTime(fromTAI(*tai).In(tai.Location()))
+}
+
+// ToUT1 converts the time to UT1 approximately, assuming DUT1 (UT1 - UTC) to
+// be 0, which is within 0.9 seconds since 1972 as leap seconds keep UTC close
+// to UT1. The result is the time object whose clock in UTC is that of UT1,
+// marked by the scale field.
+func ToUT1(v interface{}, args []interface{}) interface{} {
+	t, ok := getTimeArg(v, args)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	if err := checkUTC(v, args); err != nil {
+		return err
+	}
+	m := EncapTime(t.UTC())
+	m["scale"] = scaleUT1
+	return m
+}
+
+// FromUT1 converts the time object (or the string which guess recognizes)
+// whose clock in UTC is that of UT1, to the time in UTC approximately,
+// assuming DUT1 to be 0 as ToUT1.
+func FromUT1(v interface{}, args []interface{}) interface{} {
+	ut1, err := getScaledTimeArg(v, args)
+	if err != nil {
+		return err
+	}
+	return EncapTime(*ut1)
+}
+
+// gpsSeconds returns GPS time, i.e. the seconds since the GPS epoch without
+// leap seconds, as an integer if possible.
+func gpsSeconds(d time.Duration) interface{} {
+	if d%time.Second == 0 {
+		return int(d / time.Second)
+	}
+	return d.Seconds()
+}
+
+// ToGPS converts the time to GPS time, which is the seconds since the GPS
+// epoch (1980-01-06T00:00:00Z) without leap seconds, the week number since
+// the epoch (without rollover at 1024) and the seconds in the week.
+func ToGPS(v interface{}, args []interface{}) interface{} {
+	t, ok := getTimeArg(v, args)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	if err := checkUTC(v, args); err != nil {
+		return err
+	}
+	d := toTAI(*t).Sub(time.Unix(gpsEpoch+gpsTAIOffset, 0))
+	if d < 0 {
+		return errors.Errorf("out of range of GPS time: %s", t.Format(time.RFC3339))
+	}
+	week := d / (secondsPerWeek * time.Second)
+	return map[string]interface{}{
+		"seconds":    gpsSeconds(d),
+		"week":       int(week),
+		"timeOfWeek": gpsSeconds(d - week*secondsPerWeek*time.Second),
+	}
+}
+
+// getGPSSeconds returns the seconds since the GPS epoch of a number (or a
+// numeric string), an object of week and timeOfWeek, or the arguments of
+// week and time of week.
+func getGPSSeconds(v interface{}, args []interface{}) (float64, error) {
+	if len(args) == 2 {
+		v = map[string]interface{}{"week": args[0], "timeOfWeek": args[1]}
+	} else if len(args) == 1 {
+		v = args[0]
+	}
+	if s, ok := v.(string); ok {
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0, errors.Errorf("expected number as input, but found: %s", s)
+		}
+		return f, nil
+	}
+	if f, ok := toFloat(v); ok {
+		return f, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, errors.Errorf("expected number or object of week and timeOfWeek as input, but found unexpected type: %T", v)
+	}
+	week, ok := m["week"].(int)
+	if !ok {
+		return 0, errors.Errorf("expected integer as week, but found unexpected type: %T", m["week"])
+	}
+	tow, ok := toFloat(m["timeOfWeek"])
+	if !ok {
+		return 0, errors.Errorf("expected number as timeOfWeek, but found unexpected type: %T", m["timeOfWeek"])
+	}
+	if tow < 0 || tow >= secondsPerWeek {
+		return 0, errors.Errorf("out of range of time of week: %v", tow)
+	}
+	return float64(week)*secondsPerWeek + tow, nil
+}
+
+// FromGPS converts GPS time, i.e. the seconds since the GPS epoch, or the
+// week number and the time of week, to the time.
+func FromGPS(v interface{}, args []interface{}) interface{} {
+	sec, err := getGPSSeconds(v, args)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(sec) || sec < 0 || sec >= 1<<33 {
+		return errors.Errorf("out of range of GPS time: %v", sec)
+	}
+	whole, frac := math.Modf(sec)
+	tai := time.Unix(gpsEpoch+gpsTAIOffset+int64(whole), int64(math.Round(frac*1e9)))
+	return EncapTime(fromTAI(tai).In(time.Local))
+}
@@ -0,0 +1,209 @@
+package builtin
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// localeNames holds the localized names used in date / time formats, derived
+// from the CLDR (format context, i.e. as used in dates).
+type localeNames struct {
+	months       [12]string
+	monthsAbbr   [12]string
+	weekdays     [7]string
+	weekdaysAbbr [7]string
+	am, pm       string
+}
+
+var locales = map[string]*localeNames{
+	"en": {
+		months:       [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		monthsAbbr:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		weekdays:     [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		weekdaysAbbr: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		am:           "AM",
+		pm:           "PM",
+	},
+	"de": {
+		months:       [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		monthsAbbr:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		weekdays:     [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		weekdaysAbbr: [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		am:           "AM",
+		pm:           "PM",
+	},
+	"es": {
+		months:       [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		monthsAbbr:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		weekdays:     [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		weekdaysAbbr: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		am:           "a. m.",
+		pm:           "p. m.",
+	},
+	"fr": {
+		months:       [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		monthsAbbr:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		weekdays:     [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		weekdaysAbbr: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		am:           "AM",
+		pm:           "PM",
+	},
+	"it": {
+		months:       [12]string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		monthsAbbr:   [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		weekdays:     [7]string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		weekdaysAbbr: [7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+		am:           "AM",
+		pm:           "PM",
+	},
+	"ja": {
+		months:       [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		monthsAbbr:   [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:     [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+		weekdaysAbbr: [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		am:           "午前",
+		pm:           "午後",
+	},
+	"ko": {
+		months:       [12]string{"1월", "2월", "3월", "4월", "5월", "6월", "7월", "8월", "9월", "10월", "11월", "12월"},
+		monthsAbbr:   [12]string{"1월", "2월", "3월", "4월", "5월", "6월", "7월", "8월", "9월", "10월", "11월", "12월"},
+		weekdays:     [7]string{"일요일", "월요일", "화요일", "수요일", "목요일", "금요일", "토요일"},
+		weekdaysAbbr: [7]string{"일", "월", "화", "수", "목", "금", "토"},
+		am:           "오전",
+		pm:           "오후",
+	},
+	"nl": {
+		months:       [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		monthsAbbr:   [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		weekdays:     [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		weekdaysAbbr: [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		am:           "a.m.",
+		pm:           "p.m.",
+	},
+	"pt": {
+		months:       [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		monthsAbbr:   [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		weekdays:     [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		weekdaysAbbr: [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		am:           "AM",
+		pm:           "PM",
+	},
+	"zh": {
+		months:       [12]string{"一月", "二月", "三月", "四月", "五月", "六月", "七月", "八月", "九月", "十月", "十一月", "十二月"},
+		monthsAbbr:   [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		weekdays:     [7]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"},
+		weekdaysAbbr: [7]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"},
+		am:           "上午",
+		pm:           "下午",
+	},
+}
+
+// defaultLocale is the locale of the localized names in time objects, e.g.
+// weekday.localName, taken from --locale or the environment.
+var defaultLocale = "en"
+
+// humanizeLocale is the default language of humanize, which only --locale
+// changes not to make the output depend on the environment.
+var humanizeLocale = "en"
+
+// resolveLocale returns the key of locales for a locale name such as "ja",
+// "pt-BR" or "de_DE.UTF-8". "C" and "POSIX" are regarded as English.
+func resolveLocale(name string) (string, bool) {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	if name == "C" || name == "POSIX" {
+		return "en", true
+	}
+	name = strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+	if _, ok := locales[name]; ok {
+		return name, true
+	}
+	lang, _, _ := strings.Cut(name, "-")
+	if _, ok := locales[lang]; ok {
+		return lang, true
+	}
+	return "", false
+}
+
+// SetDefaultLocale sets the locale of the localized names in time objects,
+// and the default language of humanize.
+func SetDefaultLocale(name string) error {
+	locale, ok := resolveLocale(name)
+	if !ok {
+		return errors.Errorf("unsupported locale: %s", name)
+	}
+	defaultLocale = locale
+	humanizeLocale = locale
+	return nil
+}
+
+// SetLocaleFromEnv sets the locale of the localized names in time objects
+// from the environment, keeping English for unsupported locales.
+func SetLocaleFromEnv(name string) {
+	if locale, ok := resolveLocale(name); ok {
+		defaultLocale = locale
+	}
+}
+
+// formatLocale formats the time with the layout in Go's format, replacing the
+// names of months and weekdays, and AM / PM with the localized ones.
+func formatLocale(t time.Time, names *localeNames, layout string) string {
+	var sb strings.Builder
+	start := 0
+	for i := 0; i < len(layout); {
+		var s string
+		n := 0
+		switch {
+		case strings.HasPrefix(layout[i:], "January"):
+			s, n = names.months[t.Month()-1], len("January")
+		case strings.HasPrefix(layout[i:], "Jan"):
+			s, n = names.monthsAbbr[t.Month()-1], len("Jan")
+		case strings.HasPrefix(layout[i:], "Monday"):
+			s, n = names.weekdays[t.Weekday()], len("Monday")
+		case strings.HasPrefix(layout[i:], "Mon"):
+			s, n = names.weekdaysAbbr[t.Weekday()], len("Mon")
+		case strings.HasPrefix(layout[i:], "PM"):
+			s, n = names.am, len("PM")
+			if t.Hour() >= 12 {
+				s = names.pm
+			}
+		case strings.HasPrefix(layout[i:], "pm"):
+			s, n = strings.ToLower(names.am), len("pm")
+			if t.Hour() >= 12 {
+				s = strings.ToLower(names.pm)
+			}
+		}
+		if n == 0 {
+			i++
+			continue
+		}
+		sb.WriteString(t.Format(layout[start:i]))
+		sb.WriteString(s)
+		i += n
+		start = i
+	}
+	sb.WriteString(t.Format(layout[start:]))
+	return sb.String()
+}
+
+func FormatLocale(v interface{}, args []interface{}) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return errors.Errorf("expected string as the first argument, but found unexpected type: %T", args[0])
+	}
+	locale, ok := resolveLocale(name)
+	if !ok {
+		return errors.Errorf("unsupported locale: %s", name)
+	}
+	layout, ok := args[1].(string)
+	if !ok {
+		return errors.Errorf("expected string as the second argument, but found unexpected type: %T", args[1])
+	}
+	return formatLocale(*t, locales[locale], layout)
+}
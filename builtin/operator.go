@@ -0,0 +1,391 @@
+package builtin
+
+import (
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/pkg/errors"
+)
+
+// typeName returns the name of the type of the value used in error messages
+// of the operators.
+func typeName(v interface{}) string {
+	if _, ok := DecapTime(v); ok {
+		return "time"
+	}
+	if _, ok := DecapDuration(v); ok {
+		return "duration"
+	}
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, float64, *big.Int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func isValue(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = m[sourceKey]
+	return ok
+}
+
+// isOperands reports whether the arithmetic operators should handle the
+// operands as time and duration objects, i.e. at least one of them is a time
+// or duration object and the other is not an ordinary object or array. This
+// keeps e.g. adding fields to a time object by `. + {foo: 1}` working.
+func isOperands(l, r interface{}) bool {
+	if !isValue(l) && !isValue(r) {
+		return false
+	}
+	for _, v := range []interface{}{l, r} {
+		switch v.(type) {
+		case map[string]interface{}:
+			if !isValue(v) {
+				return false
+			}
+		case []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// isComparable reports whether the comparison operators should handle the
+// operands as time and duration objects, i.e. both of them are time or
+// duration objects.
+func isComparable(l, r interface{}) bool {
+	return isValue(l) && isValue(r)
+}
+
+// The operators of gojq, which the operators fall back to for the operands
+// other than time and duration objects. Plain numbers take the fast paths
+// below instead, not to slow down ordinary queries.
+var (
+	nativeAdd      = compileOperator("+")
+	nativeSubtract = compileOperator("-")
+	nativeMultiply = compileOperator("*")
+	nativeDivide   = compileOperator("/")
+)
+
+func compileOperator(op string) *gojq.Code {
+	q, err := gojq.Parse(".[0] " + op + " .[1]")
+	if err != nil {
+		panic(err)
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+func runOperator(code *gojq.Code, l, r interface{}) interface{} {
+	v, _ := code.Run([]interface{}{l, r}).Next()
+	return v
+}
+
+// numberOperands returns the operands as integers if both are, or as floats
+// if both are numbers otherwise. Big integers are left to gojq.
+func numberOperands(l, r interface{}) (li, ri int, lf, rf float64, isInt, ok bool) {
+	switch l := l.(type) {
+	case int:
+		switch r := r.(type) {
+		case int:
+			return l, r, 0, 0, true, true
+		case float64:
+			return 0, 0, float64(l), r, false, true
+		}
+	case float64:
+		switch r := r.(type) {
+		case int:
+			return 0, 0, l, float64(r), false, true
+		case float64:
+			return 0, 0, l, r, false, true
+		}
+	}
+	return 0, 0, 0, 0, false, false
+}
+
+func addNumbers(l, r interface{}) (interface{}, bool) {
+	li, ri, lf, rf, isInt, ok := numberOperands(l, r)
+	switch {
+	case !ok:
+		return nil, false
+	case !isInt:
+		return lf + rf, true
+	}
+	if v := li + ri; (v >= li) == (ri >= 0) {
+		return v, true
+	}
+	return nil, false
+}
+
+func subtractNumbers(l, r interface{}) (interface{}, bool) {
+	li, ri, lf, rf, isInt, ok := numberOperands(l, r)
+	switch {
+	case !ok:
+		return nil, false
+	case !isInt:
+		return lf - rf, true
+	}
+	if v := li - ri; (v <= li) == (ri >= 0) {
+		return v, true
+	}
+	return nil, false
+}
+
+func multiplyNumbers(l, r interface{}) (interface{}, bool) {
+	li, ri, lf, rf, isInt, ok := numberOperands(l, r)
+	switch {
+	case !ok:
+		return nil, false
+	case !isInt:
+		return lf * rf, true
+	}
+	if v := li * ri; ri == 0 || v/ri == li {
+		return v, true
+	}
+	return nil, false
+}
+
+// divideNumbers leaves division by zero to gojq, which reports it.
+func divideNumbers(l, r interface{}) (interface{}, bool) {
+	li, ri, lf, rf, isInt, ok := numberOperands(l, r)
+	switch {
+	case !ok:
+		return nil, false
+	case !isInt:
+		if rf == 0 {
+			return nil, false
+		}
+		return lf / rf, true
+	case ri == 0:
+		return nil, false
+	case li%ri == 0:
+		return li / ri, true
+	}
+	return float64(li) / float64(ri), true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case float64:
+		return x, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(x).Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+func scaleDuration(d time.Duration, f float64) (time.Duration, error) {
+	x := math.Round(float64(d) * f)
+	if math.IsNaN(x) || x < math.MinInt64 || x >= math.MaxInt64 {
+		return 0, errors.New("duration out of range")
+	}
+	return time.Duration(x), nil
+}
+
+// addDurations returns the sum of the durations, or an error instead of
+// wrapping around on overflow.
+func addDurations(a, b time.Duration) (time.Duration, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, errors.New("duration out of range")
+	}
+	return a + b, nil
+}
+
+// subtractDurations returns the difference of the durations, or an error
+// instead of wrapping around on overflow.
+func subtractDurations(a, b time.Duration) (time.Duration, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, errors.New("duration out of range")
+	}
+	return a - b, nil
+}
+
+func OperatorAdd(_ interface{}, args []interface{}) interface{} {
+	l, r := args[0], args[1]
+	if v, ok := addNumbers(l, r); ok {
+		return v
+	}
+	if !isOperands(l, r) {
+		return runOperator(nativeAdd, l, r)
+	}
+	if l == nil {
+		return r
+	}
+	if r == nil {
+		return l
+	}
+	lt, isLTime := DecapTime(l)
+	ld, isLDuration := DecapDuration(l)
+	rt, isRTime := DecapTime(r)
+	rd, isRDuration := DecapDuration(r)
+	switch {
+	case isLTime && isRDuration:
+		return EncapTime(lt.Add(*rd))
+	case isLDuration && isRTime:
+		return EncapTime(rt.Add(*ld))
+	case isLDuration && isRDuration:
+		d, err := addDurations(*ld, *rd)
+		if err != nil {
+			return err
+		}
+		return EncapDuration(d)
+	}
+	return errors.Errorf("cannot add: %s and %s", typeName(l), typeName(r))
+}
+
+func OperatorSubtract(_ interface{}, args []interface{}) interface{} {
+	l, r := args[0], args[1]
+	if v, ok := subtractNumbers(l, r); ok {
+		return v
+	}
+	if !isOperands(l, r) {
+		return runOperator(nativeSubtract, l, r)
+	}
+	lt, isLTime := DecapTime(l)
+	ld, isLDuration := DecapDuration(l)
+	rt, isRTime := DecapTime(r)
+	rd, isRDuration := DecapDuration(r)
+	switch {
+	case isLTime && isRDuration:
+		return EncapTime(lt.Add(-*rd))
+	case isLTime && isRTime:
+		return EncapDuration(lt.Sub(*rt))
+	case isLDuration && isRDuration:
+		d, err := subtractDurations(*ld, *rd)
+		if err != nil {
+			return err
+		}
+		return EncapDuration(d)
+	}
+	return errors.Errorf("cannot subtract: %s and %s", typeName(l), typeName(r))
+}
+
+func OperatorMultiply(_ interface{}, args []interface{}) interface{} {
+	l, r := args[0], args[1]
+	if v, ok := multiplyNumbers(l, r); ok {
+		return v
+	}
+	if !isOperands(l, r) {
+		return runOperator(nativeMultiply, l, r)
+	}
+	if _, ok := DecapDuration(l); !ok {
+		l, r = r, l
+	}
+	d, isDuration := DecapDuration(l)
+	f, isNumber := toFloat(r)
+	if !isDuration || !isNumber {
+		return errors.Errorf("cannot multiply: %s and %s", typeName(args[0]), typeName(args[1]))
+	}
+	x, err := scaleDuration(*d, f)
+	if err != nil {
+		return err
+	}
+	return EncapDuration(x)
+}
+
+func OperatorDivide(_ interface{}, args []interface{}) interface{} {
+	l, r := args[0], args[1]
+	if v, ok := divideNumbers(l, r); ok {
+		return v
+	}
+	if !isOperands(l, r) {
+		return runOperator(nativeDivide, l, r)
+	}
+	ld, isLDuration := DecapDuration(l)
+	if isLDuration {
+		if rd, ok := DecapDuration(r); ok {
+			if *rd == 0 {
+				return errors.New("cannot divide by zero duration")
+			}
+			return float64(*ld) / float64(*rd)
+		}
+		if f, ok := toFloat(r); ok {
+			if f == 0 {
+				return errors.New("cannot divide duration by zero")
+			}
+			x, err := scaleDuration(*ld, 1/f)
+			if err != nil {
+				return err
+			}
+			return EncapDuration(x)
+		}
+	}
+	return errors.Errorf("cannot divide: %s and %s", typeName(l), typeName(r))
+}
+
+// compareValues returns -1, 0 or 1 by comparing two time objects by the
+// instant, or two duration objects by the length.
+func compareValues(l, r interface{}) (int, error) {
+	if lt, ok := DecapTime(l); ok {
+		if rt, ok := DecapTime(r); ok {
+			return lt.Compare(*rt), nil
+		}
+	}
+	if ld, ok := DecapDuration(l); ok {
+		if rd, ok := DecapDuration(r); ok {
+			switch {
+			case *ld < *rd:
+				return -1, nil
+			case *ld > *rd:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, errors.Errorf("cannot compare: %s and %s", typeName(l), typeName(r))
+}
+
+// OperatorEqual compares the order keys of the operands, so that time and
+// duration objects are equal by the instant and the length also in arrays
+// and objects, as sort, unique and group_by regard them.
+func OperatorEqual(_ interface{}, args []interface{}) interface{} {
+	return gojq.Compare(orderKey(args[0]), orderKey(args[1])) == 0
+}
+
+func OperatorNotEqual(_ interface{}, args []interface{}) interface{} {
+	return gojq.Compare(orderKey(args[0]), orderKey(args[1])) != 0
+}
+
+// comparisonOperator returns the function of the comparison operator which
+// tests the result of comparing the operands.
+func comparisonOperator(test func(int) bool) BuiltinFn {
+	return func(_ interface{}, args []interface{}) interface{} {
+		l, r := args[0], args[1]
+		if !isComparable(l, r) {
+			return test(gojq.Compare(orderKey(l), orderKey(r)))
+		}
+		c, err := compareValues(l, r)
+		if err != nil {
+			return err
+		}
+		return test(c)
+	}
+}
+
+var (
+	OperatorGreater        = comparisonOperator(func(c int) bool { return c > 0 })
+	OperatorLess           = comparisonOperator(func(c int) bool { return c < 0 })
+	OperatorGreaterOrEqual = comparisonOperator(func(c int) bool { return c >= 0 })
+	OperatorLessOrEqual    = comparisonOperator(func(c int) bool { return c <= 0 })
+)
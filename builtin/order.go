@@ -0,0 +1,97 @@
+package builtin
+
+import (
+	_ "embed"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Module is the jq module included implicitly in queries. It overrides jq
+// builtins which compare values (e.g. sort, unique and group_by) so that time
+// and duration objects are compared by the instant and the length instead of
+// their fields, and the functions of the operators so that they calculate
+// with time and duration objects.
+//
+//go:embed dq.jq
+var Module string
+
+// OrderKey replaces time and duration objects in the value with objects which
+// jq orders by the instant and the length respectively. The same instant in
+// different time zones results in the same key.
+func OrderKey(v interface{}, _ []interface{}) interface{} {
+	return orderKey(v)
+}
+
+func orderKey(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if t, ok := DecapTime(x); ok {
+			return map[string]interface{}{"__dq__instant": []interface{}{int(t.Unix()), t.Nanosecond()}}
+		}
+		if d, ok := DecapDuration(x); ok {
+			return map[string]interface{}{"__dq__length": int(d.Nanoseconds())}
+		}
+		m := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			m[k] = orderKey(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(x))
+		for i, e := range x {
+			a[i] = orderKey(e)
+		}
+		return a
+	}
+	return v
+}
+
+func getTimes(v interface{}, args []interface{}) (*time.Time, []time.Time, error) {
+	t, ok := DecapTime(v)
+	if !ok {
+		return nil, nil, errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	ts := make([]time.Time, len(args))
+	for i, arg := range args {
+		u, ok := DecapTime(arg)
+		if !ok {
+			return nil, nil, errors.Errorf("expected time as argument #%d, but found unexpected type: %T", i+1, arg)
+		}
+		ts[i] = *u
+	}
+	return t, ts, nil
+}
+
+func Before(v interface{}, args []interface{}) interface{} {
+	t, ts, err := getTimes(v, args)
+	if err != nil {
+		return err
+	}
+	return t.Before(ts[0])
+}
+
+func After(v interface{}, args []interface{}) interface{} {
+	t, ts, err := getTimes(v, args)
+	if err != nil {
+		return err
+	}
+	return t.After(ts[0])
+}
+
+// Between reports whether the time is in the half-open range [from, to).
+func Between(v interface{}, args []interface{}) interface{} {
+	t, ts, err := getTimes(v, args)
+	if err != nil {
+		return err
+	}
+	return !t.Before(ts[0]) && t.Before(ts[1])
+}
+
+func SameInstant(v interface{}, args []interface{}) interface{} {
+	t, ts, err := getTimes(v, args)
+	if err != nil {
+		return err
+	}
+	return t.Equal(ts[0])
+}
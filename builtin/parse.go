@@ -0,0 +1,262 @@
+package builtin
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// assumedLocation is the location in which times of layouts without zones
+// (e.g. ANSIC, Kitchen and Stamp) are interpreted.
+var assumedLocation = time.UTC
+
+// SetAssumedTimeZone sets the time zone of times parsed from layouts without
+// zones, e.g. "Asia/Tokyo" or "Local". They are in UTC by default.
+func SetAssumedTimeZone(name string) error {
+	loc, err := loadLocation(name)
+	if err != nil {
+		return err
+	}
+	assumedLocation = loc
+	return nil
+}
+
+// AssumedLocation returns the time zone of times parsed from layouts without
+// zones, which is set by SetAssumedTimeZone.
+func AssumedLocation() *time.Location {
+	return assumedLocation
+}
+
+// defaultTZAbbreviations are the zones preferred for time zone abbreviations
+// which layouts such as RFC 1123 and UnixDate have. Where an abbreviation is
+// shared by zones of different offsets (e.g. CST and IST), the one with the
+// largest population is preferred.
+var defaultTZAbbreviations = map[string]string{
+	"ACDT": "Australia/Adelaide",
+	"ACST": "Australia/Adelaide",
+	"ADT":  "America/Halifax",
+	"AEDT": "Australia/Sydney",
+	"AEST": "Australia/Sydney",
+	"AKDT": "America/Anchorage",
+	"AKST": "America/Anchorage",
+	"AST":  "America/Halifax",
+	"AWST": "Australia/Perth",
+	"BST":  "Europe/London",
+	"CAT":  "Africa/Maputo",
+	"CDT":  "America/Chicago",
+	"CEST": "Europe/Paris",
+	"CET":  "Europe/Paris",
+	"CST":  "America/Chicago",
+	"EAT":  "Africa/Nairobi",
+	"EDT":  "America/New_York",
+	"EEST": "Europe/Athens",
+	"EET":  "Europe/Athens",
+	"EST":  "America/New_York",
+	"HKT":  "Asia/Hong_Kong",
+	"HST":  "Pacific/Honolulu",
+	"IDT":  "Asia/Jerusalem",
+	"IST":  "Asia/Kolkata",
+	"JST":  "Asia/Tokyo",
+	"KST":  "Asia/Seoul",
+	"MDT":  "America/Denver",
+	"MSK":  "Europe/Moscow",
+	"MST":  "America/Denver",
+	"NDT":  "America/St_Johns",
+	"NST":  "America/St_Johns",
+	"NZDT": "Pacific/Auckland",
+	"NZST": "Pacific/Auckland",
+	"PDT":  "America/Los_Angeles",
+	"PKT":  "Asia/Karachi",
+	"PST":  "America/Los_Angeles",
+	"SAST": "Africa/Johannesburg",
+	"WAT":  "Africa/Lagos",
+	"WEST": "Europe/Lisbon",
+	"WET":  "Europe/Lisbon",
+	"WIB":  "Asia/Jakarta",
+	"WIT":  "Asia/Jayapura",
+	"WITA": "Asia/Makassar",
+}
+
+// tzAbbreviations are the zones for abbreviations configured by
+// SetTZAbbreviations, which take precedence over the local time zone and
+// defaultTZAbbreviations.
+var tzAbbreviations = map[string]string{}
+
+// SetTZAbbreviations configures the zones for time zone abbreviations by
+// ABBR=ZONE pairs, e.g. "IST=Asia/Jerusalem".
+func SetTZAbbreviations(pairs []string) error {
+	for _, pair := range pairs {
+		abbr, name, ok := strings.Cut(pair, "=")
+		if !ok || abbr == "" || name == "" {
+			return errors.Errorf("invalid time zone abbreviation: %s (expected ABBR=ZONE)", pair)
+		}
+		if _, err := loadLocation(name); err != nil {
+			return err
+		}
+		tzAbbreviations[strings.ToUpper(abbr)] = name
+	}
+	return nil
+}
+
+var errUnableToParse = errors.New("unable to parse using the specified format")
+
+type layoutZoneKind int
+
+const (
+	layoutWithoutZone layoutZoneKind = iota
+	layoutWithAbbreviation
+	layoutWithOffset
+)
+
+func zoneKindOf(layout string) layoutZoneKind {
+	switch {
+	case strings.Contains(layout, "MST"):
+		return layoutWithAbbreviation
+	case strings.Contains(layout, "Z07"), strings.Contains(layout, "-07"):
+		return layoutWithOffset
+	}
+	return layoutWithoutZone
+}
+
+// parseTimeIn parses the value by the layout. The time is interpreted in the
+// location if the layout has no zone, and the zone abbreviation is resolved
+// by the zones preferred for it if the layout has one.
+func parseTimeIn(layout, value string, loc *time.Location) (time.Time, string, error) {
+	t, err := time.ParseInLocation(layout, value, time.UTC)
+	if err != nil {
+		return time.Time{}, "", errUnableToParse
+	}
+	switch zoneKindOf(layout) {
+	case layoutWithoutZone:
+		return localTime(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	case layoutWithAbbreviation:
+		abbr, _ := t.Zone()
+		// UTC, GMT and GMT+h (or GMT-h) are resolved by Go
+		if t.Location() == time.UTC || strings.HasPrefix(abbr, "GMT") || abbr == "UT" || abbr == "Z" {
+			return t, "", nil
+		}
+		// numeric abbreviations of zones without alphabetic ones, e.g. +09
+		// printed by date(1) in Asia/Tokyo
+		if offset, ok := parseOffset(abbr); ok {
+			wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			return withOffset(wall, offset, false), "", nil
+		}
+		return resolveTZAbbreviation(layout, value, abbr, loc)
+	}
+	return t, "", nil
+}
+
+// resolveTZAbbreviation looks for the zone which uses the abbreviation, in
+// tzAbbreviations, the location, the local time zone and
+// defaultTZAbbreviations in this order.
+func resolveTZAbbreviation(layout, value, abbr string, loc *time.Location) (time.Time, string, error) {
+	var names []string
+	if name, ok := tzAbbreviations[strings.ToUpper(abbr)]; ok {
+		names = append(names, name)
+	}
+	names = append(names, loc.String(), LocalTimeZoneName())
+	if name, ok := defaultTZAbbreviations[strings.ToUpper(abbr)]; ok {
+		names = append(names, name)
+	}
+
+	wall, _ := time.ParseInLocation(layout, value, time.UTC)
+	for _, name := range names {
+		switch name {
+		case "UTC":
+			continue
+		case "Local":
+			// Go uses the offset of the abbreviation if the location has it
+			if t, err := time.ParseInLocation(layout, value, time.Local); err == nil && t.Location() == time.Local {
+				return t, "", nil
+			}
+			continue
+		}
+		z, err := loadZone(name)
+		if err != nil {
+			continue
+		}
+		if offset, ok := z.data.abbreviationOffset(abbr, wall.Unix()); ok {
+			return wall.Add(-time.Duration(offset) * time.Second).In(z.loc), "", nil
+		}
+	}
+	return time.Time{}, "", errors.Errorf("unknown time zone abbreviation: %s", abbr)
+}
+
+// abbreviationOffset returns the offset which the abbreviation denotes in
+// the zone around the time, since some abbreviations denoted other offsets
+// in the past (e.g. NZST was +11:30 until 1946).
+func (tz *tzData) abbreviationOffset(abbr string, unix int64) (int, bool) {
+	// the local time types in effect and their periods in order
+	types := []tzType{tz.initial}
+	starts := []int64{math.MinInt64}
+	for _, t := range tz.transitions {
+		types = append(types, t.typ)
+		starts = append(starts, t.when)
+	}
+	if tz.rule != nil {
+		last := starts[len(starts)-1]
+		types = append(types, tz.rule.std, tz.rule.dst)
+		starts = append(starts, last, last)
+	}
+
+	offset, found, distance := 0, false, uint64(math.MaxUint64)
+	for i, typ := range types {
+		if typ.abbr != abbr {
+			continue
+		}
+		var d uint64
+		if unix < starts[i] {
+			d = uint64(starts[i] - unix)
+		} else if i+1 < len(starts) && starts[i+1] > starts[i] && unix >= starts[i+1] {
+			d = uint64(unix - starts[i+1])
+		}
+		if d <= distance {
+			offset, found, distance = typ.offset, true, d
+		}
+	}
+	return offset, found
+}
+
+// guessTimeFromString tries the known formats, the named formats and the
+// kinds of identifiers in order, and interprets times without zones in the
+// location. It also returns the name of the format which matched.
+func guessTimeFromString(s string, loc *time.Location) (interface{}, string) {
+	for _, f := range supportedKnownTimeFormats {
+		t, status, err := parseTimeIn(f, s, loc)
+		if err == errUnableToParse {
+			continue
+		}
+		if err != nil {
+			return err, ""
+		}
+		return EncapLocalTime(t, status), knownTimeFormatNames[f]
+	}
+	if t, status, name, ok := guessNamedFormats(s, loc); ok {
+		return EncapLocalTime(t, status), name
+	}
+	if t, kind, ok := guessIDs(s); ok {
+		return EncapTime(t), kind
+	}
+	return errors.New("unable to guess"), ""
+}
+
+// ParseIn parses the string as guess does, but interprets times without
+// zones in the zone, instead of the one specified by --assume-tz.
+func ParseIn(v interface{}, args []interface{}) interface{} {
+	s, ok := v.(string)
+	if !ok {
+		return errors.Errorf("expected string as input, but found unexpected type: %T", v)
+	}
+	name, ok := args[0].(string)
+	if !ok {
+		return errors.Errorf("expected string as zone name, but found unexpected type: %T", args[0])
+	}
+	loc, err := loadLocation(name)
+	if err != nil {
+		return err
+	}
+	t, _ := guessTimeFromString(s, loc)
+	return t
+}
@@ -0,0 +1,192 @@
+package builtin
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// endOfMonth tells how to add months to a day which the target month lacks
+// (e.g. January 31 + 1 month), and so how to count months between times.
+type endOfMonth string
+
+const (
+	// endOfMonthClamp clamps the day to the last day of the target month,
+	// i.e. January 31 + 1 month = February 28.
+	endOfMonthClamp endOfMonth = "clamp"
+	// endOfMonthOverflow carries the excess days over to the next month as Go
+	// (and add_date) does, i.e. January 31 + 1 month = March 3.
+	endOfMonthOverflow endOfMonth = "overflow"
+	// endOfMonthPreserve is the same as endOfMonthClamp, and moreover keeps the
+	// last day of a month to the last day, i.e. February 28 + 1 month = March 31
+	// in a non-leap year.
+	endOfMonthPreserve endOfMonth = "preserve"
+)
+
+func addMonths(t time.Time, months int, eom endOfMonth) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := getDaysInMonth(first)
+	switch eom {
+	case endOfMonthClamp, endOfMonthPreserve:
+		if day > last || (eom == endOfMonthPreserve && day == getDaysInMonth(t)) {
+			day = last
+		}
+	}
+	return time.Date(first.Year(), first.Month(), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
+
+type period struct {
+	negative                             bool
+	years, months, days                  int
+	hours, minutes, seconds, nanoseconds int
+}
+
+// calendarDiff returns the calendar period from u to t in the location of t.
+func calendarDiff(t, u time.Time, eom endOfMonth) period {
+	var p period
+	a, b := u.In(t.Location()), t
+	if b.Before(a) {
+		a, b = b, a
+		p.negative = true
+	}
+
+	months := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	for months > 0 && addMonths(a, months, eom).After(b) {
+		months--
+	}
+	c := addMonths(a, months, eom)
+
+	days := int(b.Sub(c).Hours() / 24)
+	for days > 0 && c.AddDate(0, 0, days).After(b) {
+		days--
+	}
+	for !c.AddDate(0, 0, days+1).After(b) {
+		days++
+	}
+	rest := b.Sub(c.AddDate(0, 0, days))
+
+	p.years, p.months, p.days = months/12, months%12, days
+	p.hours = int(rest / time.Hour)
+	p.minutes = int(rest % time.Hour / time.Minute)
+	p.seconds = int(rest % time.Minute / time.Second)
+	p.nanoseconds = int(rest % time.Second)
+	return p
+}
+
+// iso8601 formats the period as an ISO 8601 duration, e.g. P1Y2M3DT4H5M6S.
+func (p period) iso8601() string {
+	var sb strings.Builder
+	if p.negative {
+		sb.WriteByte('-')
+	}
+	sb.WriteByte('P')
+	for _, x := range []struct {
+		n    int
+		unit string
+	}{{p.years, "Y"}, {p.months, "M"}, {p.days, "D"}} {
+		if x.n != 0 {
+			fmt.Fprintf(&sb, "%d%s", x.n, x.unit)
+		}
+	}
+	if p.hours != 0 || p.minutes != 0 || p.seconds != 0 || p.nanoseconds != 0 {
+		sb.WriteByte('T')
+		if p.hours != 0 {
+			fmt.Fprintf(&sb, "%dH", p.hours)
+		}
+		if p.minutes != 0 {
+			fmt.Fprintf(&sb, "%dM", p.minutes)
+		}
+		if p.seconds != 0 || p.nanoseconds != 0 {
+			s := fmt.Sprintf("%d.%09d", p.seconds, p.nanoseconds)
+			fmt.Fprintf(&sb, "%sS", strings.TrimSuffix(strings.TrimRight(s, "0"), "."))
+		}
+	}
+	if sb.Len() == 1 || (p.negative && sb.Len() == 2) {
+		return "P0D"
+	}
+	return sb.String()
+}
+
+func (p period) encap() map[string]interface{} {
+	return map[string]interface{}{
+		"negative":    p.negative,
+		"years":       p.years,
+		"months":      p.months,
+		"days":        p.days,
+		"hours":       p.hours,
+		"minutes":     p.minutes,
+		"seconds":     p.seconds,
+		"nanoseconds": p.nanoseconds,
+		"iso8601":     p.iso8601(),
+	}
+}
+
+func getEndOfMonthOption(v interface{}) (endOfMonth, error) {
+	if v == nil {
+		return endOfMonthClamp, nil
+	}
+	opts, ok := v.(map[string]interface{})
+	if !ok {
+		return "", errors.Errorf("expected object as options, but found unexpected type: %T", v)
+	}
+	switch x := opts["endOfMonth"].(type) {
+	case nil:
+		return endOfMonthClamp, nil
+	case string:
+		switch eom := endOfMonth(x); eom {
+		case endOfMonthClamp, endOfMonthOverflow, endOfMonthPreserve:
+			return eom, nil
+		}
+	}
+	return "", errors.Errorf("unknown endOfMonth: %v (expected clamp, overflow or preserve)", opts["endOfMonth"])
+}
+
+// Diff returns the calendar period from the time given as the first argument
+// to the input time, e.g. 1 year, 2 months and 3 days. Unlike sub, which
+// returns an exact duration, the lengths of years, months and days vary.
+func Diff(v interface{}, args []interface{}) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	u, ok := DecapTime(args[0])
+	if !ok {
+		return errors.Errorf("expected time as the first argument, but found unexpected type: %T", args[0])
+	}
+	var opts interface{}
+	if len(args) > 1 {
+		opts = args[1]
+	}
+	eom, err := getEndOfMonthOption(opts)
+	if err != nil {
+		return err
+	}
+	return calendarDiff(*t, *u, eom).encap()
+}
+
+// Age returns the number of full years from the input time (e.g. a birthday)
+// to now, or to the time given as the argument. A birthday on February 29 is
+// regarded as February 28 in non-leap years.
+func Age(v interface{}, args []interface{}) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	at := time.Now().In(t.Location())
+	if len(args) == 1 {
+		u, ok := DecapTime(args[0])
+		if !ok {
+			return errors.Errorf("expected time as the first argument, but found unexpected type: %T", args[0])
+		}
+		at = u.In(t.Location())
+	}
+	p := calendarDiff(at, *t, endOfMonthClamp)
+	if p.negative {
+		return -p.years
+	}
+	return p.years
+}
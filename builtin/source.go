@@ -0,0 +1,96 @@
+package builtin
+
+import (
+	"sync"
+	"time"
+)
+
+// sourceKey is the key of time and duration objects holding the value they
+// were created from. The value is kept in a form which consists of JSON
+// values only, so that gojq builtins (e.g. tojson / fromjson, to_entries /
+// from_entries, walk and ==) can handle the objects as ordinary ones:
+//
+//	time:     ["time", <unix seconds>, <nanoseconds>, <location name>, <offset seconds>]
+//	duration: ["duration", <nanoseconds>]
+const sourceKey = "__dq__source"
+
+func timeSource(t time.Time) []interface{} {
+	_, offset := t.Zone()
+	return []interface{}{"time", int(t.Unix()), t.Nanosecond(), t.Location().String(), offset}
+}
+
+func durationSource(d time.Duration) []interface{} {
+	return []interface{}{"duration", int(d.Nanoseconds())}
+}
+
+func parseTimeSource(v interface{}) (time.Time, bool) {
+	s, ok := v.([]interface{})
+	if !ok || len(s) != 5 || s[0] != "time" {
+		return time.Time{}, false
+	}
+	sec, ok1 := sourceInt(s[1])
+	nsec, ok2 := sourceInt(s[2])
+	name, ok3 := s[3].(string)
+	offset, ok4 := sourceInt(s[4])
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return time.Time{}, false
+	}
+	t := time.Unix(int64(sec), int64(nsec))
+	return t.In(sourceLocation(t, name, offset)), true
+}
+
+func parseDurationSource(v interface{}) (time.Duration, bool) {
+	s, ok := v.([]interface{})
+	if !ok || len(s) != 2 || s[0] != "duration" {
+		return 0, false
+	}
+	ns, ok := sourceInt(s[1])
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(ns), true
+}
+
+// sourceInt accepts float64 as well as int, as numbers may have been
+// converted by arithmetic or by a JSON round-trip.
+func sourceInt(v interface{}) (int, bool) {
+	switch x := v.(type) {
+	case int:
+		return x, true
+	case float64:
+		return int(x), x == float64(int(x))
+	}
+	return 0, false
+}
+
+var sourceLocations sync.Map // location name -> *time.Location, or nil if not loadable
+
+// sourceLocation restores the location of a time. Named locations are loaded
+// again, and anything else (e.g. locations of numeric offsets) becomes a fixed
+// zone, as does a named location which disagrees with the recorded offset.
+func sourceLocation(t time.Time, name string, offset int) *time.Location {
+	var loc *time.Location
+	switch name {
+	case "UTC":
+		loc = time.UTC
+	case "Local":
+		loc = time.Local
+	default:
+		cached, ok := sourceLocations.Load(name)
+		if !ok {
+			if l, err := loadLocation(name); err == nil && name != "" {
+				cached = l
+			} else {
+				cached = (*time.Location)(nil)
+			}
+			sourceLocations.Store(name, cached)
+		}
+		loc = cached.(*time.Location)
+	}
+	if loc != nil {
+		if _, o := t.In(loc).Zone(); o == offset {
+			return loc
+		}
+	}
+	return time.FixedZone(name, offset)
+}
@@ -0,0 +1,469 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	_ "embed"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/pkg/errors"
+)
+
+// zoneSource is a source of zoneinfo data, either a directory (e.g.
+// /usr/share/zoneinfo) or a zip file (e.g. the one embedded in dq).
+type zoneSource interface {
+	read(name string) ([]byte, error)
+	names() ([]string, error)
+}
+
+type dirZoneSource string
+
+func (dir dirZoneSource) read(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(string(dir), filepath.FromSlash(name)))
+}
+
+func (dir dirZoneSource) names() ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(string(dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(string(dir), p)
+		name = filepath.ToSlash(name)
+		if d.IsDir() {
+			// copies of the zones with and without leap seconds
+			if name == "posix" || name == "right" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if name == "posixrules" || name == "localtime" || !isTZifFile(p) {
+			return nil
+		}
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+func isTZifFile(p string) bool {
+	f, err := os.Open(p)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return string(magic) == "TZif"
+}
+
+type zipZoneSource struct {
+	r *zip.Reader
+}
+
+func newZipZoneSource(data []byte) (*zipZoneSource, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return &zipZoneSource{r}, nil
+}
+
+func (z *zipZoneSource) read(name string) ([]byte, error) {
+	f, err := z.r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (z *zipZoneSource) names() ([]string, error) {
+	var names []string
+	for _, f := range z.r.File {
+		if b, err := z.read(f.Name); err == nil && bytes.HasPrefix(b, []byte("TZif")) {
+			names = append(names, f.Name)
+		}
+	}
+	return names, nil
+}
+
+// the snapshot of tzdata, which is the same as that of Go, embedded as the
+// last resort. Run `make update-tzdata` in this directory to update.
+var (
+	//go:embed tzdata/zoneinfo.zip
+	embeddedTZData []byte
+	//go:embed tzdata/version
+	embeddedTZDataVersion string
+)
+
+type embeddedZoneSource struct {
+	*zipZoneSource
+}
+
+// tzdataPath is the directory or the zip file of zoneinfo data specified by
+// SetTZData, which is used exclusively.
+var tzdataPath string
+
+// zoneSources returns the sources of zoneinfo data in the order which Go's
+// time.LoadLocation looks up, except that the embedded one (which is the same
+// as that in GOROOT) takes the place of GOROOT.
+var zoneSources = sync.OnceValues(func() ([]zoneSource, error) {
+	var sources []zoneSource
+	add := func(p string) error {
+		info, err := os.Stat(p)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			sources = append(sources, dirZoneSource(p))
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		z, err := newZipZoneSource(b)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, z)
+		return nil
+	}
+
+	if tzdataPath != "" {
+		if err := add(tzdataPath); err != nil {
+			return nil, errors.Wrap(err, "unable to load tzdata")
+		}
+		return sources, nil
+	}
+	if p := os.Getenv("ZONEINFO"); p != "" {
+		_ = add(p)
+	}
+	for _, p := range []string{"/usr/share/zoneinfo", "/usr/share/lib/zoneinfo", "/usr/lib/locale/TZ", "/etc/zoneinfo"} {
+		// ignore empty (or nearly empty) directories, e.g. in minimal images
+		if isTZifFile(filepath.Join(p, "UTC")) {
+			_ = add(p)
+		}
+	}
+	z, err := newZipZoneSource(embeddedTZData)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load embedded tzdata")
+	}
+	return append(sources, embeddedZoneSource{z}), nil
+})
+
+// SetTZData makes dq use the zoneinfo data in the directory or the zip file
+// (e.g. a build of a specific release of the IANA time zone database)
+// instead of those of the system and the embedded one, unless the path is
+// empty. It also loads the local time zone again if necessary, so it should
+// be called before anything else in this file regardless of the path. The
+// sources of zoneinfo data are looked up on the first use, except that the
+// path is validated here.
+func SetTZData(path string) error {
+	tzdataPath = path
+	if path != "" {
+		if _, err := zoneSources(); err != nil {
+			return err
+		}
+	}
+	return reloadLocal()
+}
+
+// reloadLocal loads the local time zone named by TZ environment variable
+// from the zoneinfo data of dq, if the zone cannot be loaded by Go (e.g. no
+// zoneinfo in the system) or tzdata is specified. It replaces time.Local,
+// which every function using the local time zone reads.
+func reloadLocal() error {
+	name := strings.TrimPrefix(os.Getenv("TZ"), ":")
+	if name == "" || filepath.IsAbs(name) || name == "UTC" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err == nil && tzdataPath == "" {
+		return nil
+	}
+	b, err := loadZoneData(name)
+	if err != nil {
+		// leave it to Go, as it is ignored without --tzdata too
+		return nil
+	}
+	loc, err := time.LoadLocationFromTZData("Local", b)
+	if err != nil {
+		return nil
+	}
+	time.Local = loc
+	return nil
+}
+
+// defaultTZ is the name of the default time zone set by SetDefaultTimeZone.
+var defaultTZ string
+
+// SetDefaultTimeZone makes the time zone (e.g. "Asia/Tokyo") the local time
+// zone of dq regardless of TZ environment variable, so that the functions
+// using the local time zone behave the same on every machine.
+func SetDefaultTimeZone(name string) error {
+	switch name {
+	case "Local":
+		// the local time zone stays as it is
+		return nil
+	case "", "UTC":
+		time.Local = time.UTC
+		defaultTZ = "UTC"
+		return nil
+	}
+	b, err := loadZoneData(name)
+	if err != nil {
+		return err
+	}
+	loc, err := time.LoadLocationFromTZData("Local", b)
+	if err != nil {
+		return errors.Wrapf(err, "unable to load zone %s", name)
+	}
+	time.Local = loc
+	defaultTZ = name
+	return nil
+}
+
+// LocalTimeZoneName returns the name of the local time zone, i.e. the one
+// set by SetDefaultTimeZone, TZ environment variable or /etc/localtime, or
+// "Local" if it is unknown.
+func LocalTimeZoneName() string {
+	if defaultTZ != "" {
+		return defaultTZ
+	}
+	if name, ok := os.LookupEnv("TZ"); ok {
+		name = strings.TrimPrefix(name, ":")
+		if name == "" {
+			return "UTC"
+		}
+		if !filepath.IsAbs(name) {
+			return name
+		}
+	} else if p, err := filepath.EvalSymlinks("/etc/localtime"); err == nil {
+		if _, name, ok := strings.Cut(p, "/zoneinfo/"); ok {
+			return name
+		}
+	}
+	return "Local"
+}
+
+// TZDataVersion returns the version of the zoneinfo data in use (e.g.
+// 2026c), or "unknown" if it is not recorded in the data.
+func TZDataVersion() string {
+	sources, err := zoneSources()
+	if err != nil || len(sources) == 0 {
+		return "unknown"
+	}
+	switch s := sources[0].(type) {
+	case embeddedZoneSource:
+		return strings.TrimSpace(embeddedTZDataVersion)
+	case dirZoneSource:
+		return tzdataVersion(s)
+	case *zipZoneSource:
+		return tzdataVersion(s)
+	}
+	return "unknown"
+}
+
+// tzdataVersion looks for the version in the files which the build of the
+// IANA time zone database has, i.e. tzdata.zi (e.g. "# version 2026c"),
+// +VERSION or version.
+func tzdataVersion(s zoneSource) string {
+	if b, err := s.read("tzdata.zi"); err == nil {
+		line, _, _ := strings.Cut(string(b), "\n")
+		if v, ok := strings.CutPrefix(line, "# version "); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	for _, name := range []string{"+VERSION", "version"} {
+		if b, err := s.read(name); err == nil {
+			return strings.TrimSpace(string(b))
+		}
+	}
+	return "unknown"
+}
+
+var errNoZoneinfo = errors.New("no zoneinfo data found")
+
+type zone struct {
+	data *tzData
+	loc  *time.Location
+}
+
+var zones sync.Map
+
+func loadZoneData(name string) ([]byte, error) {
+	if name == "" || strings.Contains(name, "..") || strings.Contains(name, `\`) || path.IsAbs(name) {
+		return nil, errors.Errorf("invalid zone name: %s", name)
+	}
+	sources, err := zoneSources()
+	if err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if b, err := source.read(name); err == nil {
+			return b, nil
+		}
+	}
+	return nil, errors.Errorf("unknown time zone %s", name)
+}
+
+func loadZone(name string) (*zone, error) {
+	if z, ok := zones.Load(name); ok {
+		return z.(*zone), nil
+	}
+	b, err := loadZoneData(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := parseTZif(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load zone %s", name)
+	}
+	loc, err := time.LoadLocationFromTZData(name, b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load zone %s", name)
+	}
+	z := &zone{data, loc}
+	zones.Store(name, z)
+	return z, nil
+}
+
+// loadLocation works as time.LoadLocation does, with the zoneinfo data of dq.
+func loadLocation(name string) (*time.Location, error) {
+	switch name {
+	case "", "UTC":
+		return time.UTC, nil
+	case "Local":
+		return time.Local, nil
+	}
+	z, err := loadZone(name)
+	if err != nil {
+		return nil, err
+	}
+	return z.loc, nil
+}
+
+// TZVersion returns the version of the zoneinfo data in use.
+func TZVersion(_ interface{}, _ []interface{}) interface{} {
+	return TZDataVersion()
+}
+
+// TZList returns the names of the zones in the zoneinfo data.
+func TZList(_ interface{}, _ []interface{}) interface{} {
+	sources, err := zoneSources()
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		return errNoZoneinfo
+	}
+	names, err := sources[0].names()
+	if err != nil {
+		return errors.Wrap(err, "unable to list zones")
+	}
+	sort.Strings(names)
+	result := make([]interface{}, len(names))
+	for i, name := range names {
+		result[i] = name
+	}
+	return result
+}
+
+func getZoneArg(v interface{}) (*zone, error) {
+	name, ok := v.(string)
+	if !ok {
+		return nil, errors.Errorf("expected string as zone name, but found unexpected type: %T", v)
+	}
+	return loadZone(name)
+}
+
+// TZInfo describes the zone, as of now.
+func TZInfo(_ interface{}, args []interface{}) interface{} {
+	z, err := getZoneArg(args[0])
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(z.loc)
+	abbr, offset := now.Zone()
+	current := tzType{offset, now.IsDST(), abbr}
+
+	// the standard and daylight saving time in the coming year
+	var std, dst interface{}
+	transitions, _ := z.data.transitionsBetween(now.Unix(), now.AddDate(1, 0, 0).Unix())
+	for _, t := range append([]tzTransition{{now.Unix(), current}}, transitions...) {
+		if t.typ.isDST && dst == nil {
+			dst = map[string]interface{}{"abbreviation": t.typ.abbr, "offsetSeconds": t.typ.offset}
+		}
+		if !t.typ.isDST && std == nil {
+			std = map[string]interface{}{"abbreviation": t.typ.abbr, "offsetSeconds": t.typ.offset}
+		}
+	}
+
+	seen := map[string]bool{z.data.initial.abbr: true}
+	for _, t := range z.data.transitions {
+		seen[t.typ.abbr] = true
+	}
+	if r := z.data.rule; r != nil {
+		seen[r.std.abbr], seen[r.dst.abbr] = true, true
+	}
+	abbrs := make([]string, 0, len(seen))
+	for a := range seen {
+		abbrs = append(abbrs, a)
+	}
+	sort.Strings(abbrs)
+	abbreviations := make([]interface{}, len(abbrs))
+	for i, a := range abbrs {
+		abbreviations[i] = a
+	}
+
+	return map[string]interface{}{
+		"name":          z.loc.String(),
+		"abbreviation":  abbr,
+		"offsetSeconds": offset,
+		"dst":           current.isDST,
+		"observesDst":   dst != nil,
+		"standard":      std,
+		"daylight":      dst,
+		"abbreviations": abbreviations,
+	}
+}
+
+// TZTransitions emits the changes of the local time type (offset,
+// abbreviation or DST) of the zone in [from, to).
+func TZTransitions(_ interface{}, args []interface{}) gojq.Iter {
+	z, err := getZoneArg(args[0])
+	if err != nil {
+		return gojq.NewIter(err)
+	}
+	from, ok := DecapTime(args[1])
+	if !ok {
+		return gojq.NewIter(errors.Errorf("expected time as the second argument, but found unexpected type: %T", args[1]))
+	}
+	to, ok := DecapTime(args[2])
+	if !ok {
+		return gojq.NewIter(errors.Errorf("expected time as the third argument, but found unexpected type: %T", args[2]))
+	}
+
+	transitions, before := z.data.transitionsBetween(from.Unix(), to.Unix())
+	results := make([]interface{}, len(transitions))
+	for i, t := range transitions {
+		results[i] = map[string]interface{}{
+			"time":   EncapTime(time.Unix(t.when, 0).In(z.loc)),
+			"before": before[i].encap(),
+			"after":  t.typ.encap(),
+		}
+	}
+	return gojq.NewIter(results...)
+}
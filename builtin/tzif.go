@@ -0,0 +1,360 @@
+package builtin
+
+import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tzType is a local time type of a zone.
+type tzType struct {
+	offset int
+	isDST  bool
+	abbr   string
+}
+
+func (t tzType) encap() map[string]interface{} {
+	return map[string]interface{}{
+		"offsetSeconds": t.offset,
+		"abbreviation":  t.abbr,
+		"dst":           t.isDST,
+	}
+}
+
+type tzTransition struct {
+	when int64
+	typ  tzType
+}
+
+// tzData is the content of a TZif file (RFC 8536).
+type tzData struct {
+	initial     tzType
+	transitions []tzTransition
+	rule        *tzRule // from the footer, for times after the last transition
+}
+
+var errInvalidTZif = errors.New("invalid zoneinfo data")
+
+// parseTZif parses the data in the Time Zone Information Format. The version
+// 2+ data is used if available.
+func parseTZif(data []byte) (*tzData, error) {
+	if len(data) < 44 || string(data[:4]) != "TZif" {
+		return nil, errInvalidTZif
+	}
+	version := data[4]
+	timeSize := 4
+	counts, data := readTZifHeader(data)
+	if version >= '2' {
+		// skip the version 1 data
+		size := counts[3]*4 + counts[3] + counts[4]*6 + counts[5] + counts[2]*8 + counts[1] + counts[0]
+		if len(data) < size+44 {
+			return nil, errInvalidTZif
+		}
+		counts, data = readTZifHeader(data[size:])
+		timeSize = 8
+	}
+	isutcnt, isstdcnt, leapcnt, timecnt, typecnt, charcnt := counts[0], counts[1], counts[2], counts[3], counts[4], counts[5]
+	size := timecnt*timeSize + timecnt + typecnt*6 + charcnt + leapcnt*(timeSize+4) + isstdcnt + isutcnt
+	if typecnt == 0 || len(data) < size {
+		return nil, errInvalidTZif
+	}
+
+	times, data := data[:timecnt*timeSize], data[timecnt*timeSize:]
+	indices, data := data[:timecnt], data[timecnt:]
+	infos, data := data[:typecnt*6], data[typecnt*6:]
+	chars, data := data[:charcnt], data[charcnt:]
+	data = data[leapcnt*(timeSize+4)+isstdcnt+isutcnt:]
+
+	types := make([]tzType, typecnt)
+	for i := range types {
+		info := infos[i*6:]
+		abbr := ""
+		if idx := int(info[5]); idx < len(chars) {
+			abbr = string(chars[idx:])
+			if end := strings.IndexByte(abbr, 0); end >= 0 {
+				abbr = abbr[:end]
+			}
+		}
+		types[i] = tzType{int(int32(binary.BigEndian.Uint32(info))), info[4] != 0, abbr}
+	}
+
+	tz := &tzData{initial: types[0]}
+	for i := 0; i < timecnt; i++ {
+		var when int64
+		if timeSize == 8 {
+			when = int64(binary.BigEndian.Uint64(times[i*8:]))
+		} else {
+			when = int64(int32(binary.BigEndian.Uint32(times[i*4:])))
+		}
+		if int(indices[i]) >= len(types) {
+			return nil, errInvalidTZif
+		}
+		tz.transitions = append(tz.transitions, tzTransition{when, types[indices[i]]})
+	}
+
+	if timeSize == 8 && len(data) > 2 && data[0] == '\n' {
+		if end := strings.IndexByte(string(data[1:]), '\n'); end >= 0 && end > 0 {
+			rule, err := parseTZRule(string(data[1 : end+1]))
+			if err != nil {
+				return nil, err
+			}
+			tz.rule = rule
+		}
+	}
+	return tz, nil
+}
+
+func readTZifHeader(data []byte) ([]int, []byte) {
+	counts := make([]int, 6)
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint32(data[20+i*4:]))
+	}
+	return counts, data[44:]
+}
+
+// tzRule is a rule in the format of the TZ environment variable of POSIX,
+// e.g. CET-1CEST,M3.5.0,M10.5.0/3.
+type tzRule struct {
+	std, dst   tzType
+	start, end tzRuleDate // of the daylight saving time, nil if not observed
+}
+
+type tzRuleDate struct {
+	kind   byte // 'J' (Julian day ignoring Feb 29), 'D' (zero-based day) or 'M'
+	day    int
+	week   int
+	month  int
+	offset int // seconds since the local midnight
+}
+
+func parseTZRule(s string) (*tzRule, error) {
+	p := &tzRuleParser{s: s}
+	r := &tzRule{}
+	r.std.abbr = p.name()
+	r.std.offset = -p.offset()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.done() {
+		r.dst = r.std
+		return r, nil
+	}
+	r.dst = tzType{abbr: p.name(), offset: r.std.offset + 3600, isDST: true}
+	if !p.done() && p.s[0] != ',' {
+		r.dst.offset = -p.offset()
+	}
+	if p.done() {
+		// no rule, use the rules of the U.S. as POSIX does
+		p.s = ",M3.2.0,M11.1.0"
+	}
+	p.expect(',')
+	r.start = p.date()
+	p.expect(',')
+	r.end = p.date()
+	if p.err == nil && !p.done() {
+		p.err = errors.Errorf("invalid TZ rule: %s", s)
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return r, nil
+}
+
+type tzRuleParser struct {
+	s   string
+	err error
+}
+
+func (p *tzRuleParser) done() bool {
+	return p.err != nil || p.s == ""
+}
+
+func (p *tzRuleParser) fail() {
+	if p.err == nil {
+		p.err = errors.New("invalid TZ rule")
+	}
+}
+
+func (p *tzRuleParser) expect(c byte) {
+	if p.done() || p.s[0] != c {
+		p.fail()
+		return
+	}
+	p.s = p.s[1:]
+}
+
+func (p *tzRuleParser) name() string {
+	if p.done() {
+		p.fail()
+		return ""
+	}
+	if p.s[0] == '<' {
+		end := strings.IndexByte(p.s, '>')
+		if end < 0 {
+			p.fail()
+			return ""
+		}
+		name := p.s[1:end]
+		p.s = p.s[end+1:]
+		return name
+	}
+	i := 0
+	for i < len(p.s) && (p.s[i] >= 'A' && p.s[i] <= 'Z' || p.s[i] >= 'a' && p.s[i] <= 'z') {
+		i++
+	}
+	if i < 3 {
+		p.fail()
+	}
+	name := p.s[:i]
+	p.s = p.s[i:]
+	return name
+}
+
+func (p *tzRuleParser) number() int {
+	i := 0
+	for i < len(p.s) && p.s[i] >= '0' && p.s[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(p.s[:i])
+	if err != nil {
+		p.fail()
+	}
+	p.s = p.s[i:]
+	return n
+}
+
+// offset parses [+-]hh[:mm[:ss]] and returns it in seconds.
+func (p *tzRuleParser) offset() int {
+	if p.done() {
+		p.fail()
+		return 0
+	}
+	sign := 1
+	if p.s[0] == '+' || p.s[0] == '-' {
+		if p.s[0] == '-' {
+			sign = -1
+		}
+		p.s = p.s[1:]
+	}
+	secs := p.number() * 3600
+	for _, unit := range []int{60, 1} {
+		if p.done() || p.s[0] != ':' {
+			break
+		}
+		p.s = p.s[1:]
+		secs += p.number() * unit
+	}
+	return sign * secs
+}
+
+func (p *tzRuleParser) date() tzRuleDate {
+	var d tzRuleDate
+	if p.done() {
+		p.fail()
+		return d
+	}
+	switch p.s[0] {
+	case 'J':
+		p.s = p.s[1:]
+		d.kind, d.day = 'J', p.number()
+	case 'M':
+		p.s = p.s[1:]
+		d.kind, d.month = 'M', p.number()
+		p.expect('.')
+		d.week = p.number()
+		p.expect('.')
+		d.day = p.number()
+	default:
+		d.kind, d.day = 'D', p.number()
+	}
+	d.offset = 2 * 3600
+	if !p.done() && p.s[0] == '/' {
+		p.s = p.s[1:]
+		d.offset = p.offset()
+	}
+	return d
+}
+
+// at returns the instant of the date in the year, where the local time is in
+// the offset.
+func (d tzRuleDate) at(year, offset int) int64 {
+	var t time.Time
+	switch d.kind {
+	case 'J':
+		t = time.Date(year, time.January, d.day, 0, 0, 0, 0, time.UTC)
+		if isLeapYear(year) && d.day >= 60 {
+			t = t.AddDate(0, 0, 1)
+		}
+	case 'D':
+		t = time.Date(year, time.January, d.day+1, 0, 0, 0, 0, time.UTC)
+	case 'M':
+		// the d.day-th day of the week in the d.week-th week, where 5 means
+		// the last one
+		first := time.Date(year, time.Month(d.month), 1, 0, 0, 0, 0, time.UTC)
+		day := 1 + (d.day-int(first.Weekday())+7)%7 + (d.week-1)*7
+		for day > daysIn(year, time.Month(d.month)) {
+			day -= 7
+		}
+		t = first.AddDate(0, 0, day-1)
+	}
+	return t.Unix() + int64(d.offset) - int64(offset)
+}
+
+func isLeapYear(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// transitions returns the transitions by the rule in the years.
+func (r *tzRule) transitions(from, to int) []tzTransition {
+	if r.start.kind == 0 {
+		return nil
+	}
+	var result []tzTransition
+	for year := from; year <= to; year++ {
+		result = append(result,
+			tzTransition{r.start.at(year, r.std.offset), r.dst},
+			tzTransition{r.end.at(year, r.dst.offset), r.std},
+		)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].when < result[j].when })
+	return result
+}
+
+// transitionsBetween returns all the transitions in [from, to), including
+// those by the rule, and the local time type in effect just before each.
+func (tz *tzData) transitionsBetween(from, to int64) (result []tzTransition, before []tzType) {
+	all := tz.transitions
+	if tz.rule != nil {
+		last := int64(-1 << 63)
+		if len(all) > 0 {
+			last = all[len(all)-1].when
+		}
+		startYear := time.Unix(max(last, from), 0).UTC().Year() - 1
+		for _, t := range tz.rule.transitions(startYear, time.Unix(to, 0).UTC().Year()) {
+			if t.when > last {
+				all = append(all[:len(all):len(all)], t)
+			}
+		}
+	}
+
+	prev := tz.initial
+	for _, t := range all {
+		if t.when >= to {
+			break
+		}
+		if t.when >= from && t.typ != prev {
+			result = append(result, t)
+			before = append(before, prev)
+		}
+		prev = t.typ
+	}
+	return result, before
+}
@@ -0,0 +1,204 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type era struct {
+	name   string // in kanji
+	symbol string // the squared ligature, e.g. ㋿
+	romaji string
+	abbr   string
+	year   int // the start date in the Gregorian calendar
+	month  time.Month
+	day    int
+}
+
+// eras is the list of the Japanese eras since Meiji, in chronological order.
+// Meiji starts from the day of the proclamation; dates before 1873 (when
+// Japan adopted the Gregorian calendar) are treated in the Gregorian calendar.
+var eras = []*era{
+	{"明治", "㍾", "Meiji", "M", 1868, time.October, 23},
+	{"大正", "㍽", "Taisho", "T", 1912, time.July, 30},
+	{"昭和", "㍼", "Showa", "S", 1926, time.December, 25},
+	{"平成", "㍻", "Heisei", "H", 1989, time.January, 8},
+	{"令和", "㋿", "Reiwa", "R", 2019, time.May, 1},
+}
+
+func (e *era) start() string {
+	return fmt.Sprintf("%04d-%02d-%02d", e.year, e.month, e.day)
+}
+
+// startsBy reports whether the era has started by the date.
+func (e *era) startsBy(year int, month time.Month, day int) bool {
+	if year != e.year {
+		return year > e.year
+	}
+	if month != e.month {
+		return month > e.month
+	}
+	return day >= e.day
+}
+
+// eraOf returns the era and the year in it of the date of t in its location.
+func eraOf(t time.Time) (*era, int, bool) {
+	year, month, day := t.Date()
+	for i := len(eras) - 1; i >= 0; i-- {
+		if eras[i].startsBy(year, month, day) {
+			return eras[i], year - eras[i].year + 1, true
+		}
+	}
+	return nil, 0, false
+}
+
+// includeEra tells EncapTime to add the era sub-object to time objects.
+var includeEra = false
+
+// SetIncludeEra sets whether time objects have the era sub-object.
+func SetIncludeEra(b bool) {
+	includeEra = b
+}
+
+func encapEra(t time.Time) interface{} {
+	e, year, ok := eraOf(t)
+	if !ok {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":   e.name,
+		"romaji": e.romaji,
+		"abbr":   e.abbr,
+		"year":   year,
+		"start":  e.start(),
+	}
+}
+
+type warekiOptions struct {
+	style  string
+	gannen bool
+}
+
+func getWarekiOptions(v interface{}) (*warekiOptions, error) {
+	opts := &warekiOptions{style: "kanji", gannen: true}
+	if v == nil {
+		return opts, nil
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("expected object as options, but found unexpected type: %T", v)
+	}
+	if x, ok := m["style"]; ok {
+		switch x {
+		case "kanji", "romaji", "abbr":
+			opts.style = x.(string)
+		default:
+			return nil, errors.Errorf("unknown style: %v (expected kanji, romaji or abbr)", x)
+		}
+	}
+	if x, ok := m["gannen"]; ok {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, errors.Errorf("expected boolean as gannen, but found unexpected type: %T", x)
+		}
+		opts.gannen = b
+	}
+	return opts, nil
+}
+
+// ToWareki formats the date of the time in the Japanese era, e.g.
+// 令和8年10月18日 or R8.10.18. The first year is written as 元年 in kanji.
+func ToWareki(v interface{}, args []interface{}) interface{} {
+	t, ok := DecapTime(v)
+	if !ok {
+		return errors.Errorf("expected time as input, but found unexpected type: %T", v)
+	}
+	var optsArg interface{}
+	if len(args) == 1 {
+		optsArg = args[0]
+	}
+	opts, err := getWarekiOptions(optsArg)
+	if err != nil {
+		return err
+	}
+
+	e, year, ok := eraOf(*t)
+	if !ok {
+		return errors.Errorf("no Japanese era before %s %s", eras[0].romaji, eras[0].start())
+	}
+	switch opts.style {
+	case "romaji":
+		return fmt.Sprintf("%s %d.%d.%d", e.romaji, year, t.Month(), t.Day())
+	case "abbr":
+		return fmt.Sprintf("%s%d.%02d.%02d", e.abbr, year, t.Month(), t.Day())
+	}
+	y := strconv.Itoa(year)
+	if year == 1 && opts.gannen {
+		y = "元"
+	}
+	return fmt.Sprintf("%s%s年%d月%d日", e.name, y, t.Month(), t.Day())
+}
+
+var (
+	reWarekiKanji = regexp.MustCompile(`^(\p{Han}{2}|[㍾㍽㍼㍻㋿])\s*(元|\d+)\s*年\s*(\d+)\s*月\s*(\d+)\s*日$`)
+	reWarekiLatin = regexp.MustCompile(`^([A-Za-zō]+)\s*(\d+)\s*[./-]\s*(\d+)\s*[./-]\s*(\d+)$`)
+)
+
+var fullwidthDigits = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+	"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+	"．", ".", "／", "/", "－", "-",
+)
+
+func findEra(name string) (*era, bool) {
+	name = strings.ReplaceAll(name, "ō", "o")
+	for _, e := range eras {
+		if name == e.name || name == e.symbol || strings.EqualFold(name, e.romaji) || strings.EqualFold(name, e.abbr) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// FromWareki parses a date in the Japanese era, in either the kanji (e.g.
+// 令和元年5月1日), romanised (e.g. Reiwa 1.5.1) or abbreviated (e.g.
+// R1.05.01) notation, and returns the time at midnight in local time.
+func FromWareki(v interface{}, args []interface{}) interface{} {
+	s, ok := getStringArg(v, args)
+	if !ok {
+		return errors.Errorf("expected string, but found unexpected type: %T", v)
+	}
+
+	normalized := strings.TrimSpace(fullwidthDigits.Replace(s))
+	m := reWarekiKanji.FindStringSubmatch(normalized)
+	if m == nil {
+		m = reWarekiLatin.FindStringSubmatch(normalized)
+	}
+	if m == nil {
+		return errors.Errorf("unable to parse as Japanese era: %s", s)
+	}
+	e, ok := findEra(m[1])
+	if !ok {
+		return errors.Errorf("unknown Japanese era: %s", m[1])
+	}
+	year := 1
+	if m[2] != "元" {
+		year, _ = strconv.Atoi(m[2])
+	}
+	month, _ := strconv.Atoi(m[3])
+	day, _ := strconv.Atoi(m[4])
+
+	d := date(e.year+year-1, time.Month(month), day)
+	if year < 1 || d.Month() != time.Month(month) || d.Day() != day {
+		return errors.Errorf("no such date: %s", s)
+	}
+	if actual, _, _ := eraOf(d); actual != e {
+		return errors.Errorf("no such date in %s (%s): %s", e.romaji, e.name, s)
+	}
+	return encapLocalTime(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -9,10 +9,12 @@ import (
 	"github.com/bitbears-dev/dq/builtin"
 	"github.com/itchyny/gojq"
 	"github.com/jessevdk/go-flags"
+	"github.com/pkg/errors"
 )
 
 type CLI struct {
-	version string
+	version    string
+	timeOutput timeOutputFormatter
 }
 
 func NewCLI(version string) *CLI {
@@ -39,11 +41,84 @@ func (c *CLI) run(args []string) error {
 		return err
 	}
 
+	tzdata := options.TZData
+	if tzdata == "" {
+		tzdata = os.Getenv("DQ_TZDATA")
+	}
+	if err := builtin.SetTZData(tzdata); err != nil {
+		return err
+	}
+
+	leapSeconds := options.LeapSeconds
+	if leapSeconds == "" {
+		leapSeconds = os.Getenv("DQ_LEAP_SECONDS")
+	}
+	if leapSeconds != "" {
+		if err := builtin.LoadLeapSeconds(leapSeconds); err != nil {
+			return err
+		}
+	}
+
+	tz := options.TZ
+	if tz == "" {
+		tz = os.Getenv("DQ_TZ")
+	}
+	if tz != "" {
+		if err := builtin.SetDefaultTimeZone(tz); err != nil {
+			return err
+		}
+	}
+
 	if options.Version {
-		fmt.Println(c.version)
+		version := c.version
+		if version == "" {
+			version = "(devel)"
+		}
+		fmt.Printf("%s (tzdata %s)\n", version, builtin.TZDataVersion())
 		return nil
 	}
 
+	if len(options.TimeKeys) > 0 && !options.InputLogfmt {
+		return errors.New("--logfmt is required for --time-key")
+	}
+
+	c.timeOutput, err = newTimeOutputFormatter(options.TimeOutput, options.TimeLayout)
+	if err != nil {
+		return err
+	}
+
+	if options.Locale != "" {
+		if err := builtin.SetDefaultLocale(options.Locale); err != nil {
+			return err
+		}
+	} else if locale := localeFromEnv(); locale != "" {
+		builtin.SetLocaleFromEnv(locale)
+	}
+
+	builtin.SetIncludeEra(options.Era)
+	builtin.SetIncludeEpochs(options.Epochs)
+	if options.Seed != nil {
+		builtin.SetSeed(*options.Seed)
+	}
+	if options.FiscalYearStart != "" || options.FiscalCalendar != "" || options.FiscalYearName != "" {
+		if err := builtin.SetFiscalCalendar(options.FiscalYearStart, options.FiscalCalendar, options.FiscalYearName); err != nil {
+			return err
+		}
+	}
+	if options.AssumeTZ != "" {
+		if err := builtin.SetAssumedTimeZone(options.AssumeTZ); err != nil {
+			return err
+		}
+	}
+	if err := builtin.SetTZAbbreviations(options.TZAbbreviations); err != nil {
+		return err
+	}
+	if options.DSTPolicy != "" {
+		if err := builtin.SetDSTPolicy(options.DSTPolicy); err != nil {
+			return err
+		}
+	}
+
 	queryString := "."
 	if len(queryAndInputFiles) > 0 {
 		queryString = queryAndInputFiles[0]
@@ -57,13 +132,24 @@ func (c *CLI) run(args []string) error {
 	if err != nil {
 		return err
 	}
+	rewriteOperators(query)
 
 	iter := c.createInputIter(queryString, inputFiles)
 	defer iter.Close()
 
+	if options.DecorateTimes || options.DecorateKey != "" || options.DecoratePath != "" {
+		d, err := newTimeDecorator(options.DecorateKey, options.DecoratePath)
+		if err != nil {
+			return err
+		}
+		iter = newDecoratedInputIter(iter, d)
+	}
+
 	code, err := gojq.Compile(query,
 		gojq.WithFunction("guess", 0, 1, builtin.Guess),
 		gojq.WithFunction("g", 0, 1, builtin.Guess),
+		gojq.WithFunction("guess_explain", 0, 1, builtin.GuessExplain),
+		gojq.WithFunction("parse_in", 1, 1, builtin.ParseIn),
 		gojq.WithFunction("fromunix", 0, 1, builtin.FromUnix),
 		gojq.WithFunction("from_unix", 0, 1, builtin.FromUnix),
 		gojq.WithFunction("fromunixmilli", 0, 1, builtin.FromUnixMilli),
@@ -139,21 +225,109 @@ func (c *CLI) run(args []string) error {
 		gojq.WithFunction("from_stampnano", 0, 1, builtin.FromKnownTimeFormat(time.StampNano)),
 		gojq.WithFunction("tostampnano", 0, 1, builtin.ToKnownTimeFormat(time.StampNano)),
 		gojq.WithFunction("to_stampnano", 0, 1, builtin.ToKnownTimeFormat(time.StampNano)),
+		gojq.WithFunction("fromrfc2822", 0, 1, builtin.FromNamedFormat("rfc2822")),
+		gojq.WithFunction("from_rfc2822", 0, 1, builtin.FromNamedFormat("rfc2822")),
+		gojq.WithFunction("torfc2822", 0, 1, builtin.ToNamedFormat("rfc2822")),
+		gojq.WithFunction("to_rfc2822", 0, 1, builtin.ToNamedFormat("rfc2822")),
+		gojq.WithFunction("fromhttpdate", 0, 1, builtin.FromNamedFormat("httpdate")),
+		gojq.WithFunction("from_httpdate", 0, 1, builtin.FromNamedFormat("httpdate")),
+		gojq.WithFunction("tohttpdate", 0, 1, builtin.ToNamedFormat("httpdate")),
+		gojq.WithFunction("to_httpdate", 0, 1, builtin.ToNamedFormat("httpdate")),
+		gojq.WithFunction("fromutctime", 0, 1, builtin.FromNamedFormat("utctime")),
+		gojq.WithFunction("from_utctime", 0, 1, builtin.FromNamedFormat("utctime")),
+		gojq.WithFunction("toutctime", 0, 1, builtin.ToNamedFormat("utctime")),
+		gojq.WithFunction("to_utctime", 0, 1, builtin.ToNamedFormat("utctime")),
+		gojq.WithFunction("fromgeneralizedtime", 0, 1, builtin.FromNamedFormat("generalizedtime")),
+		gojq.WithFunction("from_generalizedtime", 0, 1, builtin.FromNamedFormat("generalizedtime")),
+		gojq.WithFunction("togeneralizedtime", 0, 1, builtin.ToNamedFormat("generalizedtime")),
+		gojq.WithFunction("to_generalizedtime", 0, 1, builtin.ToNamedFormat("generalizedtime")),
+		gojq.WithFunction("fromsql", 0, 1, builtin.FromNamedFormat("sql")),
+		gojq.WithFunction("from_sql", 0, 1, builtin.FromNamedFormat("sql")),
+		gojq.WithFunction("tosql", 0, 1, builtin.ToNamedFormat("sql")),
+		gojq.WithFunction("to_sql", 0, 1, builtin.ToNamedFormat("sql")),
+		gojq.WithFunction("fromgitraw", 0, 1, builtin.FromNamedFormat("gitraw")),
+		gojq.WithFunction("from_gitraw", 0, 1, builtin.FromNamedFormat("gitraw")),
+		gojq.WithFunction("togitraw", 0, 1, builtin.ToNamedFormat("gitraw")),
+		gojq.WithFunction("to_gitraw", 0, 1, builtin.ToNamedFormat("gitraw")),
+		gojq.WithFunction("fromw3c", 0, 1, builtin.FromNamedFormat("w3c")),
+		gojq.WithFunction("from_w3c", 0, 1, builtin.FromNamedFormat("w3c")),
+		gojq.WithFunction("tow3c", 0, 1, builtin.ToNamedFormat("w3c")),
+		gojq.WithFunction("to_w3c", 0, 1, builtin.ToNamedFormat("w3c")),
+		gojq.WithFunction("fromatom", 0, 1, builtin.FromNamedFormat("atom")),
+		gojq.WithFunction("from_atom", 0, 1, builtin.FromNamedFormat("atom")),
+		gojq.WithFunction("toatom", 0, 1, builtin.ToNamedFormat("atom")),
+		gojq.WithFunction("to_atom", 0, 1, builtin.ToNamedFormat("atom")),
 		gojq.WithFunction("add_date", 3, 3, builtin.AddDate),
 		gojq.WithFunction("add", 1, 1, builtin.Add),
 		gojq.WithFunction("sub", 1, 1, builtin.Sub),
+		gojq.WithFunction("diff", 1, 2, builtin.Diff),
+		gojq.WithFunction("age", 0, 1, builtin.Age),
+		gojq.WithFunction("humanize", 0, 2, builtin.Humanize),
+		gojq.WithFunction("format_locale", 2, 2, builtin.FormatLocale),
+		gojq.WithFunction("to_wareki", 0, 1, builtin.ToWareki),
+		gojq.WithFunction("from_wareki", 0, 1, builtin.FromWareki),
+		gojq.WithFunction("to_calendar", 1, 1, builtin.ToCalendar),
+		gojq.WithFunction("from_calendar", 4, 4, builtin.FromCalendar),
+		gojq.WithFunction("start_of_fiscal", 1, 1, builtin.StartOfFiscal),
+		gojq.WithFunction("end_of_fiscal", 1, 1, builtin.EndOfFiscal),
+		gojq.WithFunction("tz_list", 0, 0, builtin.TZList),
+		gojq.WithFunction("tz_version", 0, 0, builtin.TZVersion),
+		gojq.WithFunction("tz_info", 1, 1, builtin.TZInfo),
+		gojq.WithIterFunction("tz_transitions", 3, 3, builtin.TZTransitions),
+		gojq.WithFunction("from_excel", 0, 1, builtin.FromEpoch("excel")),
+		gojq.WithFunction("to_excel", 0, 1, builtin.ToEpoch("excel")),
+		gojq.WithFunction("from_excel1904", 0, 1, builtin.FromEpoch("excel1904")),
+		gojq.WithFunction("to_excel1904", 0, 1, builtin.ToEpoch("excel1904")),
+		gojq.WithFunction("from_ole", 0, 1, builtin.FromEpoch("ole")),
+		gojq.WithFunction("to_ole", 0, 1, builtin.ToEpoch("ole")),
+		gojq.WithFunction("from_filetime", 0, 1, builtin.FromEpoch("filetime")),
+		gojq.WithFunction("to_filetime", 0, 1, builtin.ToEpoch("filetime")),
+		gojq.WithFunction("from_ldap", 0, 1, builtin.FromEpoch("filetime")),
+		gojq.WithFunction("to_ldap", 0, 1, builtin.ToEpoch("filetime")),
+		gojq.WithFunction("from_ticks", 0, 1, builtin.FromEpoch("ticks")),
+		gojq.WithFunction("to_ticks", 0, 1, builtin.ToEpoch("ticks")),
+		gojq.WithFunction("from_apple", 0, 1, builtin.FromEpoch("apple")),
+		gojq.WithFunction("to_apple", 0, 1, builtin.ToEpoch("apple")),
+		gojq.WithFunction("from_webkit", 0, 1, builtin.FromEpoch("webkit")),
+		gojq.WithFunction("to_webkit", 0, 1, builtin.ToEpoch("webkit")),
+		gojq.WithFunction("from_jd", 0, 1, builtin.FromEpoch("jd")),
+		gojq.WithFunction("to_jd", 0, 1, builtin.ToEpoch("jd")),
+		gojq.WithFunction("from_mjd", 0, 1, builtin.FromEpoch("mjd")),
+		gojq.WithFunction("to_mjd", 0, 1, builtin.ToEpoch("mjd")),
+		gojq.WithFunction("from_uuid", 0, 1, builtin.FromID("uuid")),
+		gojq.WithFunction("from_ulid", 0, 1, builtin.FromID("ulid")),
+		gojq.WithFunction("from_ksuid", 0, 1, builtin.FromID("ksuid")),
+		gojq.WithFunction("from_objectid", 0, 1, builtin.FromID("objectid")),
+		gojq.WithFunction("from_snowflake", 1, 1, builtin.FromSnowflake),
+		gojq.WithFunction("to_tai", 0, 1, builtin.ToTAI),
+		gojq.WithFunction("from_tai", 0, 1, builtin.FromTAI),
+		gojq.WithFunction("to_ut1", 0, 1, builtin.ToUT1),
+		gojq.WithFunction("from_ut1", 0, 1, builtin.FromUT1),
+		gojq.WithFunction("to_gps", 0, 1, builtin.ToGPS),
+		gojq.WithFunction("from_gps", 0, 2, builtin.FromGPS),
+		gojq.WithFunction("to_uuidv7", 0, 1, builtin.ToID("uuid")),
+		gojq.WithFunction("to_ulid", 0, 1, builtin.ToID("ulid")),
+		gojq.WithFunction("to_ksuid", 0, 1, builtin.ToID("ksuid")),
+		gojq.WithFunction("to_objectid", 0, 1, builtin.ToID("objectid")),
 		gojq.WithFunction("clock", 0, 0, builtin.Clock),
 		gojq.WithFunction("date", 0, 0, builtin.Date),
 		gojq.WithFunction("utc", 0, 0, builtin.UTC),
 		gojq.WithFunction("local", 0, 0, builtin.Local),
 		gojq.WithFunction("indays", 0, 1, builtin.InDays),
 		gojq.WithFunction("in_days", 0, 1, builtin.InDays),
+		gojq.WithFunction("weeks", 0, 1, builtin.Weeks),
+		gojq.WithFunction("days", 0, 1, builtin.Days),
 		gojq.WithFunction("hours", 0, 1, builtin.Hours),
 		gojq.WithFunction("minutes", 0, 1, builtin.Minutes),
 		gojq.WithFunction("seconds", 0, 1, builtin.Seconds),
 		gojq.WithFunction("milliseconds", 0, 1, builtin.Milliseconds),
 		gojq.WithFunction("microseconds", 0, 1, builtin.Microseconds),
 		gojq.WithFunction("nanoseconds", 0, 1, builtin.Nanoseconds),
+		gojq.WithFunction("negate", 0, 0, builtin.Negate),
+		gojq.WithFunction("abs", 0, 0, builtin.Abs),
+		gojq.WithFunction("scale", 1, 1, builtin.Scale),
+		gojq.WithFunction("truncate", 1, 1, builtin.Truncate),
+		gojq.WithFunction("round", 1, 1, builtin.Round),
 		gojq.WithFunction("today", 0, 0, builtin.Today),
 		gojq.WithFunction("todayutc", 0, 0, builtin.TodayUTC),
 		gojq.WithFunction("today_utc", 0, 0, builtin.TodayUTC),
@@ -163,6 +337,24 @@ func (c *CLI) run(args []string) error {
 		gojq.WithFunction("tomorrow", 0, 0, builtin.Tomorrow),
 		gojq.WithFunction("tomorrowutc", 0, 0, builtin.TomorrowUTC),
 		gojq.WithFunction("tomorrow_utc", 0, 0, builtin.TomorrowUTC),
+		gojq.WithIterFunction("find_times", 0, 1, builtin.FindTimes),
+		gojq.WithFunction("before", 1, 1, builtin.Before),
+		gojq.WithFunction("after", 1, 1, builtin.After),
+		gojq.WithFunction("between", 2, 2, builtin.Between),
+		gojq.WithFunction("same_instant", 1, 1, builtin.SameInstant),
+		gojq.WithFunction("_dq_key", 0, 0, builtin.OrderKey),
+		gojq.WithFunction("_dq_add", 2, 2, builtin.OperatorAdd),
+		gojq.WithFunction("_dq_subtract", 2, 2, builtin.OperatorSubtract),
+		gojq.WithFunction("_dq_multiply", 2, 2, builtin.OperatorMultiply),
+		gojq.WithFunction("_dq_divide", 2, 2, builtin.OperatorDivide),
+		gojq.WithFunction("_dq_equal", 2, 2, builtin.OperatorEqual),
+		gojq.WithFunction("_dq_notequal", 2, 2, builtin.OperatorNotEqual),
+		gojq.WithFunction("_dq_greater", 2, 2, builtin.OperatorGreater),
+		gojq.WithFunction("_dq_less", 2, 2, builtin.OperatorLess),
+		gojq.WithFunction("_dq_greatereq", 2, 2, builtin.OperatorGreaterOrEqual),
+		gojq.WithFunction("_dq_lesseq", 2, 2, builtin.OperatorLessOrEqual),
+		gojq.WithModuleLoader(&moduleLoader{}),
+		gojq.WithVariables([]string{"$__tz__"}),
 	)
 	if err != nil {
 		return err
@@ -176,13 +368,23 @@ func (c *CLI) createInputIter(query string, args []string) (iter inputIter) {
 		return newGuessedInputIter(time.Now())
 	}
 	var newIter func(io.Reader, string) inputIter
+	var raw bool
 	switch {
+	case options.ExtractTimes:
+		newIter = newExtractTimesInputIter
+	case options.InputLogfmt:
+		newIter = func(r io.Reader, fname string) inputIter {
+			return newLogfmtInputIter(r, fname, options.TimeKeys)
+		}
+	case options.InputSyslog:
+		newIter = newSyslogInputIter
 	case options.InputRaw:
 		if options.InputSlurp {
 			newIter = newReadAllIter
 		} else {
 			newIter = newRawInputIter
 		}
+		raw = true
 	case options.InputStream:
 		newIter = newStreamInputIter
 	default:
@@ -190,7 +392,7 @@ func (c *CLI) createInputIter(query string, args []string) (iter inputIter) {
 	}
 	if options.InputSlurp {
 		defer func() {
-			if options.InputRaw {
+			if raw {
 				iter = newSlurpRawInputIter(iter)
 			} else {
 				iter = newSlurpInputIter(iter)
@@ -203,6 +405,17 @@ func (c *CLI) createInputIter(query string, args []string) (iter inputIter) {
 	return newFilesInputIter(newIter, args, os.Stdin)
 }
 
+// localeFromEnv returns the locale for date / time in the environment
+// variables, in the order of precedence of POSIX.
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
 func isStdinConnectedToPipe() bool {
 	stat, err := os.Stdin.Stat()
 	if err != nil {
@@ -222,7 +435,7 @@ func (c *CLI) process(iter inputIter, code *gojq.Code) error {
 			c.printError(er)
 			continue
 		}
-		if er := c.printValues(code.Run(v)); er != nil {
+		if er := c.printValues(code.Run(v, builtin.LocalTimeZoneName())); er != nil {
 			c.printError(er)
 		}
 	}
@@ -262,9 +475,9 @@ func (c *CLI) createMarshaler() marshaler {
 	} else if i := options.OutputIndent; i != nil {
 		indent = *i
 	}
-	f := newEncoder(options.OutputTab, indent)
+	f := newEncoder(options.OutputTab, indent, c.timeOutput)
 	if options.OutputRaw || options.OutputJoin || options.OutputNul {
-		return &rawMarshaler{f}
+		return &rawMarshaler{f, c.timeOutput}
 	}
 	return f
 }
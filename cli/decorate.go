@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bitbears-dev/dq/builtin"
+	"github.com/pkg/errors"
+)
+
+// timeDecorator replaces values which guess recognizes in the input with
+// time objects. Without any restrictions by key or path, only strings in
+// known date / time formats are replaced, as numbers (and strings of digits)
+// are too likely to be something other than Unix time, and identifiers such
+// as UUIDs are to be kept as they are.
+type timeDecorator struct {
+	key  *regexp.Regexp
+	glob []string
+}
+
+func newTimeDecorator(keyPattern, pathGlob string) (*timeDecorator, error) {
+	d := &timeDecorator{}
+	if keyPattern != "" {
+		re, err := regexp.Compile(keyPattern)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid key pattern for --decorate-key")
+		}
+		d.key = re
+	}
+	if pathGlob != "" {
+		d.glob = strings.Split(strings.TrimPrefix(pathGlob, "."), ".")
+		for _, g := range d.glob {
+			if _, err := path.Match(g, ""); err != nil {
+				return nil, errors.Wrap(err, "invalid glob for --decorate-path")
+			}
+		}
+	}
+	return d, nil
+}
+
+func (d *timeDecorator) decorate(v interface{}) interface{} {
+	return d.walk(v, "", nil)
+}
+
+func (d *timeDecorator) walk(v interface{}, key string, p []string) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		if _, ok := builtin.DecapTime(x); ok {
+			return x
+		}
+		for k, e := range x {
+			x[k] = d.walk(e, k, append(p, k))
+		}
+		return x
+	case []interface{}:
+		for i, e := range x {
+			x[i] = d.walk(e, key, append(p, strconv.Itoa(i)))
+		}
+		return x
+	}
+
+	if d.key != nil && !d.key.MatchString(key) {
+		return v
+	}
+	if d.glob != nil && !matchPathGlob(d.glob, p) {
+		return v
+	}
+
+	restricted := d.key != nil || d.glob != nil
+	switch x := v.(type) {
+	case string:
+		if !restricted && (builtin.IsAllDigits(x) || builtin.LooksLikeID(x)) {
+			return v
+		}
+	case json.Number:
+		if !restricted {
+			return v
+		}
+		if i, err := x.Int64(); err == nil {
+			v = int(i)
+		} else if f, err := x.Float64(); err == nil {
+			v = f
+		}
+	case int, float64:
+		if !restricted {
+			return v
+		}
+	default:
+		return v
+	}
+
+	t := builtin.Guess(v, nil)
+	if _, ok := builtin.DecapTime(t); !ok {
+		return v
+	}
+	return t
+}
+
+// matchPathGlob reports whether the path matches the glob. Each segment of
+// the glob is matched by path.Match, and "**" matches any number of segments.
+func matchPathGlob(glob, p []string) bool {
+	if len(glob) == 0 {
+		return len(p) == 0
+	}
+	if glob[0] == "**" {
+		for i := 0; i <= len(p); i++ {
+			if matchPathGlob(glob[1:], p[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(p) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], p[0]); !ok {
+		return false
+	}
+	return matchPathGlob(glob[1:], p[1:])
+}
+
+type decoratedInputIter struct {
+	inputIter
+	d *timeDecorator
+}
+
+func newDecoratedInputIter(iter inputIter, d *timeDecorator) inputIter {
+	return &decoratedInputIter{iter, d}
+}
+
+func (i *decoratedInputIter) Next() (interface{}, bool) {
+	v, ok := i.inputIter.Next()
+	if !ok {
+		return nil, false
+	}
+	if _, ok := v.(error); ok {
+		return v, true
+	}
+	return i.d.decorate(v), true
+}
@@ -9,22 +9,22 @@ import (
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 	"unicode/utf8"
 )
 
 type encoder struct {
-	out    io.Writer
-	w      *bytes.Buffer
-	tab    bool
-	indent int
-	depth  int
-	buf    [64]byte
+	out        io.Writer
+	w          *bytes.Buffer
+	tab        bool
+	indent     int
+	depth      int
+	buf        [64]byte
+	timeOutput timeOutputFormatter
 }
 
-func newEncoder(tab bool, indent int) *encoder {
+func newEncoder(tab bool, indent int, timeOutput timeOutputFormatter) *encoder {
 	// reuse the buffer in multiple calls of marshal
-	return &encoder{w: new(bytes.Buffer), tab: tab, indent: indent}
+	return &encoder{w: new(bytes.Buffer), tab: tab, indent: indent, timeOutput: timeOutput}
 }
 
 func (e *encoder) flush() error {
@@ -67,11 +67,14 @@ func (e *encoder) encode(v interface{}) error {
 			return err
 		}
 	case map[string]interface{}:
+		if e.timeOutput != nil {
+			if x, ok := e.timeOutput(v); ok {
+				return e.encode(x)
+			}
+		}
 		if err := e.encodeMap(v); err != nil {
 			return err
 		}
-	case time.Time:
-		return nil
 	default:
 		panic(fmt.Sprintf("invalid type: %[1]T (%[1]v)", v))
 	}
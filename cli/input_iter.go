@@ -256,6 +256,156 @@ func (i *rawInputIter) Name() string {
 	return i.fname
 }
 
+type logfmtInputIter struct {
+	iter     inputIter
+	timeKeys []string
+	line     int
+	err      error
+}
+
+func newLogfmtInputIter(r io.Reader, fname string, timeKeys []string) inputIter {
+	return &logfmtInputIter{iter: newRawInputIter(r, fname), timeKeys: timeKeys}
+}
+
+func (i *logfmtInputIter) Next() (interface{}, bool) {
+	if i.err != nil {
+		return nil, false
+	}
+	for {
+		v, ok := i.iter.Next()
+		if !ok {
+			return nil, false
+		}
+		if err, ok := v.(error); ok {
+			i.err = err
+			return err, true
+		}
+		i.line++
+		line := v.(string)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m, err := parseLogfmt(line)
+		if err != nil {
+			return &logfmtParseError{i.iter.Name(), i.line, err.Error()}, true
+		}
+		for _, key := range i.timeKeys {
+			s, ok := m[key].(string)
+			if !ok {
+				continue
+			}
+			t := builtin.Guess(s, nil)
+			if _, ok := builtin.DecapTime(t); ok {
+				m[key] = t
+			}
+		}
+		return m, true
+	}
+}
+
+func (i *logfmtInputIter) Close() error {
+	i.err = io.EOF
+	return i.iter.Close()
+}
+
+func (i *logfmtInputIter) Name() string {
+	return i.iter.Name()
+}
+
+type syslogInputIter struct {
+	iter inputIter
+	now  time.Time
+	line int
+	err  error
+}
+
+func newSyslogInputIter(r io.Reader, fname string) inputIter {
+	return &syslogInputIter{iter: newRawInputIter(r, fname), now: time.Now()}
+}
+
+func (i *syslogInputIter) Next() (interface{}, bool) {
+	if i.err != nil {
+		return nil, false
+	}
+	for {
+		v, ok := i.iter.Next()
+		if !ok {
+			return nil, false
+		}
+		if err, ok := v.(error); ok {
+			i.err = err
+			return err, true
+		}
+		i.line++
+		line := v.(string)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m, err := parseSyslog(line, i.now)
+		if err != nil {
+			return &syslogParseError{i.iter.Name(), i.line, err.Error()}, true
+		}
+		return m, true
+	}
+}
+
+func (i *syslogInputIter) Close() error {
+	i.err = io.EOF
+	return i.iter.Close()
+}
+
+func (i *syslogInputIter) Name() string {
+	return i.iter.Name()
+}
+
+type extractTimesInputIter struct {
+	iter  inputIter
+	line  int
+	found []interface{}
+}
+
+func newExtractTimesInputIter(r io.Reader, fname string) inputIter {
+	return &extractTimesInputIter{iter: newRawInputIter(r, fname)}
+}
+
+func (i *extractTimesInputIter) Next() (interface{}, bool) {
+	for len(i.found) == 0 {
+		v, ok := i.iter.Next()
+		if !ok {
+			return nil, false
+		}
+		if _, ok := v.(error); ok {
+			return v, true
+		}
+		i.line++
+		iter := builtin.FindTimes(v, nil)
+		for {
+			t, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if m, ok := t.(map[string]interface{}); ok {
+				if match, ok := m["match"].(map[string]interface{}); ok {
+					match["line"] = i.line
+				}
+			}
+			i.found = append(i.found, t)
+		}
+	}
+	v := i.found[0]
+	i.found = i.found[1:]
+	return v, true
+}
+
+func (i *extractTimesInputIter) Close() error {
+	i.found = nil
+	return i.iter.Close()
+}
+
+func (i *extractTimesInputIter) Name() string {
+	return i.iter.Name()
+}
+
 type streamInputIter struct {
 	stream *jsonStream
 	ir     *inputReader
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type logfmtParseError struct {
+	fname string
+	line  int
+	err   string
+}
+
+func (err *logfmtParseError) Error() string {
+	return fmt.Sprintf("invalid logfmt: %s:%d: %s", err.fname, err.line, err.err)
+}
+
+// parseLogfmt parses a line of logfmt (e.g. `ts=2022-10-23T23:03:01Z level=info msg="hello world"`)
+// into an object. A key without a value (e.g. `debug`) is interpreted as true.
+func parseLogfmt(line string) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	i := 0
+	for {
+		for i < len(line) && line[i] <= ' ' {
+			i++
+		}
+		if i == len(line) {
+			return m, nil
+		}
+
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		if i == start {
+			return nil, errors.Errorf("unexpected character %q at column %d", line[i], i+1)
+		}
+		key := line[start:i]
+
+		if i == len(line) || line[i] != '=' {
+			m[key] = true
+			continue
+		}
+		i++
+
+		if i < len(line) && line[i] == '"' {
+			value, n, err := unquoteLogfmtValue(line[i:])
+			if err != nil {
+				return nil, errors.Errorf("%s at column %d", err, i+1)
+			}
+			m[key] = value
+			i += n
+			continue
+		}
+
+		start = i
+		for i < len(line) && line[i] > ' ' && line[i] != '"' {
+			i++
+		}
+		m[key] = line[start:i]
+	}
+}
+
+// unquoteLogfmtValue unquotes the quoted value at the beginning of s, and
+// returns the value and the number of bytes consumed.
+func unquoteLogfmtValue(s string) (string, int, error) {
+	escaped := false
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				// be lenient about escape sequences Go does not know
+				value = strings.ReplaceAll(s[1:i], `\"`, `"`)
+			}
+			return value, i + 1, nil
+		}
+	}
+	return "", 0, errors.New("unterminated quoted value")
+}
@@ -7,10 +7,16 @@ type marshaler interface {
 }
 
 type rawMarshaler struct {
-	m marshaler
+	m          marshaler
+	timeOutput timeOutputFormatter
 }
 
 func (m *rawMarshaler) marshal(v interface{}, w io.Writer) error {
+	if m.timeOutput != nil {
+		if x, ok := m.timeOutput(v); ok {
+			v = x
+		}
+	}
 	if s, ok := v.(string); ok {
 		_, err := w.Write([]byte(s))
 		return err
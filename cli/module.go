@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"github.com/bitbears-dev/dq/builtin"
+	"github.com/itchyny/gojq"
+	"github.com/pkg/errors"
+)
+
+// moduleLoader provides builtin.Module as an init module, which gojq includes
+// in the query before compiling it. Loading other modules is not supported.
+type moduleLoader struct{}
+
+func (*moduleLoader) LoadModule(name string) (*gojq.Query, error) {
+	return nil, errors.Errorf("module not found: %q", name)
+}
+
+func (*moduleLoader) LoadInitModules() ([]*gojq.Query, error) {
+	q, err := gojq.Parse(builtin.Module)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the builtin module")
+	}
+	return []*gojq.Query{q}, nil
+}
@@ -1,17 +1,39 @@
 package cli
 
 var options struct {
-	Version       bool `short:"v" long:"version" description:"print version"`
-	InputRaw      bool `short:"R" long:"raw-input" description:"read input as raw strings"`
-	InputSlurp    bool `short:"s" long:"slurp" description:"read all inputs into an array"`
-	InputStream   bool `long:"stream" description:"parse input in stream fashion"`
-	OutputCompact bool `short:"c" long:"compact-output" description:"compact output"`
-	OutputRaw     bool `short:"r" long:"raw-output" description:"output raw strings"`
-	OutputJoin    bool `short:"j" long:"join-output" description:"stop printing a new line after each output"`
-	OutputNul     bool `short:"0" long:"nul-output" description:"print NUL after each output"`
-	OutputColor   bool `short:"C" long:"color-output" description:"colorize output even if piped"`
-	OutputMono    bool `short:"M" long:"monochrome-output" description:"stop colorizing output"`
-	OutputYAML    bool `long:"yaml-output" description:"output by YAML"`
-	OutputIndent  *int `long:"indent" description:"number of spaces for indentation"`
-	OutputTab     bool `long:"tab" description:"use tabs for indentation"`
+	Version         bool     `short:"v" long:"version" description:"print version"`
+	InputRaw        bool     `short:"R" long:"raw-input" description:"read input as raw strings"`
+	InputSlurp      bool     `short:"s" long:"slurp" description:"read all inputs into an array"`
+	InputStream     bool     `long:"stream" description:"parse input in stream fashion"`
+	ExtractTimes    bool     `long:"extract-times" description:"read input as raw strings and emit timestamps found in each line"`
+	InputLogfmt     bool     `long:"logfmt" description:"read input as logfmt (key=value pairs) lines"`
+	InputSyslog     bool     `long:"syslog" description:"read input as syslog (RFC 5424 or RFC 3164) lines"`
+	TimeKeys        []string `long:"time-key" description:"key of logfmt input to be converted to time by guess (can be specified multiple times)"`
+	DecorateTimes   bool     `long:"decorate-times" description:"replace values in the input which guess recognizes with time objects"`
+	DecorateKey     string   `long:"decorate-key" description:"regular expression restricting keys to be decorated by --decorate-times"`
+	DecoratePath    string   `long:"decorate-path" description:"glob (e.g. events.*.created_at) restricting paths to be decorated by --decorate-times"`
+	OutputCompact   bool     `short:"c" long:"compact-output" description:"compact output"`
+	OutputRaw       bool     `short:"r" long:"raw-output" description:"output raw strings"`
+	OutputJoin      bool     `short:"j" long:"join-output" description:"stop printing a new line after each output"`
+	OutputNul       bool     `short:"0" long:"nul-output" description:"print NUL after each output"`
+	OutputColor     bool     `short:"C" long:"color-output" description:"colorize output even if piped"`
+	OutputMono      bool     `short:"M" long:"monochrome-output" description:"stop colorizing output"`
+	OutputYAML      bool     `long:"yaml-output" description:"output by YAML"`
+	OutputIndent    *int     `long:"indent" description:"number of spaces for indentation"`
+	OutputTab       bool     `long:"tab" description:"use tabs for indentation"`
+	TimeOutput      string   `long:"time-output" description:"output time and duration objects as scalar values" choice:"rfc3339" choice:"rfc3339nano" choice:"unix" choice:"unixmilli" choice:"unixmicro" choice:"unixnano" choice:"layout"`
+	Locale          string   `long:"locale" description:"locale of weekday.localName and monthLocalName (e.g. ja, de, pt-BR), defaulting to LC_ALL, LC_TIME or LANG, and of humanize"`
+	Epochs          bool     `long:"epochs" description:"add epochs (Excel serial date, FILETIME, Julian Day etc.) to time objects"`
+	Era             bool     `long:"era" description:"add era (Japanese era) to time objects"`
+	FiscalYearStart string   `long:"fiscal-year-start" description:"month in which fiscal years begin (e.g. 4 or apr), which adds fiscal to time objects"`
+	FiscalCalendar  string   `long:"fiscal-calendar" description:"fiscal calendar, either monthly or week-based" choice:"monthly" choice:"4-4-5" choice:"4-5-4" choice:"5-4-4"`
+	FiscalYearName  string   `long:"fiscal-year-name" description:"name fiscal years by the calendar year in which they start or end (default: end)" choice:"start" choice:"end"`
+	DSTPolicy       string   `long:"dst-policy" description:"how to interpret local times which are ambiguous or nonexistent due to DST transitions (default: shift_forward)" choice:"earlier" choice:"later" choice:"error" choice:"shift_forward"`
+	TZ              string   `long:"tz" description:"time zone used as the local time zone instead of TZ environment variable (e.g. Asia/Tokyo), defaults to DQ_TZ"`
+	AssumeTZ        string   `long:"assume-tz" description:"time zone of times parsed from formats without zones e.g. ANSIC, Kitchen and Stamp (default: UTC)"`
+	TZAbbreviations []string `long:"tz-abbreviation" description:"zone preferred for a time zone abbreviation in parsed times, in ABBR=ZONE form e.g. IST=Asia/Jerusalem (can be specified multiple times)"`
+	TZData          string   `long:"tzdata" description:"directory or zip file of zoneinfo data to use instead of the system's and the embedded one, also for the local time zone named by TZ, defaults to DQ_TZDATA"`
+	LeapSeconds     string   `long:"leap-seconds" description:"leap-seconds.list file of IERS to use instead of the embedded leap second table, defaults to DQ_LEAP_SECONDS"`
+	TimeLayout      string   `long:"time-layout" description:"layout in Go's format (e.g. 2006-01-02) used by --time-output=layout"`
+	Seed            *int64   `long:"seed" description:"seed of the random parts of identifiers generated by to_ulid, to_uuidv7, to_ksuid and to_objectid, for deterministic output"`
 }
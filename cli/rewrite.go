@@ -0,0 +1,162 @@
+package cli
+
+import "github.com/itchyny/gojq"
+
+// operatorFuncs maps binary operators to the functions which handle time and
+// duration objects, and the other operands as gojq does. gojq compiles the
+// binary operators into direct calls of its internal functions, while the
+// update-assignment operators (e.g. +=) look the functions up by name. So
+// builtin.Module overrides the functions for the latter, and
+// rewriteOperators makes the binary operators call the functions directly.
+var operatorFuncs = map[gojq.Operator]string{
+	gojq.OpAdd: "_dq_add",
+	gojq.OpSub: "_dq_subtract",
+	gojq.OpMul: "_dq_multiply",
+	gojq.OpDiv: "_dq_divide",
+	gojq.OpEq:  "_dq_equal",
+	gojq.OpNe:  "_dq_notequal",
+	gojq.OpGt:  "_dq_greater",
+	gojq.OpLt:  "_dq_less",
+	gojq.OpGe:  "_dq_greatereq",
+	gojq.OpLe:  "_dq_lesseq",
+}
+
+// rewriteOperators rewrites the binary operators and the unary minus in the
+// query into calls of the functions which handle time and duration objects.
+func rewriteOperators(q *gojq.Query) {
+	if q == nil {
+		return
+	}
+	for _, fd := range q.FuncDefs {
+		rewriteOperators(fd.Body)
+	}
+	rewriteOperatorsInTerm(q.Term)
+	rewriteOperators(q.Left)
+	rewriteOperators(q.Right)
+	if name, ok := operatorFuncs[q.Op]; ok {
+		*q = gojq.Query{
+			Meta:     q.Meta,
+			Imports:  q.Imports,
+			FuncDefs: q.FuncDefs,
+			Term: &gojq.Term{
+				Type: gojq.TermTypeFunc,
+				Func: &gojq.Func{Name: name, Args: []*gojq.Query{q.Left, q.Right}},
+			},
+		}
+	}
+}
+
+func rewriteOperatorsInTerm(t *gojq.Term) {
+	if t == nil {
+		return
+	}
+	rewriteOperatorsInIndex(t.Index)
+	if t.Func != nil {
+		for _, arg := range t.Func.Args {
+			rewriteOperators(arg)
+		}
+	}
+	if t.Object != nil {
+		for _, kv := range t.Object.KeyVals {
+			rewriteOperatorsInString(kv.KeyString)
+			rewriteOperators(kv.KeyQuery)
+			if kv.Val != nil {
+				for _, v := range kv.Val.Queries {
+					rewriteOperators(v)
+				}
+			}
+		}
+	}
+	if t.Array != nil {
+		rewriteOperators(t.Array.Query)
+	}
+	if t.Unary != nil {
+		rewriteOperatorsInTerm(t.Unary.Term)
+		if t.Unary.Op == gojq.OpSub && t.Unary.Term.Type != gojq.TermTypeNumber {
+			*t = gojq.Term{
+				Type: gojq.TermTypeQuery,
+				Query: &gojq.Query{
+					Left: &gojq.Query{Term: t.Unary.Term},
+					Op:   gojq.OpPipe,
+					Right: &gojq.Query{Term: &gojq.Term{
+						Type: gojq.TermTypeFunc,
+						Func: &gojq.Func{Name: "_negate"},
+					}},
+				},
+				SuffixList: t.SuffixList,
+			}
+		}
+	}
+	rewriteOperatorsInString(t.Str)
+	if t.If != nil {
+		rewriteOperators(t.If.Cond)
+		rewriteOperators(t.If.Then)
+		for _, elif := range t.If.Elif {
+			rewriteOperators(elif.Cond)
+			rewriteOperators(elif.Then)
+		}
+		rewriteOperators(t.If.Else)
+	}
+	if t.Try != nil {
+		rewriteOperators(t.Try.Body)
+		rewriteOperators(t.Try.Catch)
+	}
+	if t.Reduce != nil {
+		rewriteOperatorsInTerm(t.Reduce.Term)
+		rewriteOperatorsInPattern(t.Reduce.Pattern)
+		rewriteOperators(t.Reduce.Start)
+		rewriteOperators(t.Reduce.Update)
+	}
+	if t.Foreach != nil {
+		rewriteOperatorsInTerm(t.Foreach.Term)
+		rewriteOperatorsInPattern(t.Foreach.Pattern)
+		rewriteOperators(t.Foreach.Start)
+		rewriteOperators(t.Foreach.Update)
+		rewriteOperators(t.Foreach.Extract)
+	}
+	if t.Label != nil {
+		rewriteOperators(t.Label.Body)
+	}
+	rewriteOperators(t.Query)
+	for _, s := range t.SuffixList {
+		rewriteOperatorsInIndex(s.Index)
+		if s.Bind != nil {
+			for _, p := range s.Bind.Patterns {
+				rewriteOperatorsInPattern(p)
+			}
+			rewriteOperators(s.Bind.Body)
+		}
+	}
+}
+
+func rewriteOperatorsInIndex(x *gojq.Index) {
+	if x == nil {
+		return
+	}
+	rewriteOperatorsInString(x.Str)
+	rewriteOperators(x.Start)
+	rewriteOperators(x.End)
+}
+
+func rewriteOperatorsInString(s *gojq.String) {
+	if s == nil {
+		return
+	}
+	for _, q := range s.Queries {
+		rewriteOperators(q)
+	}
+}
+
+func rewriteOperatorsInPattern(p *gojq.Pattern) {
+	if p == nil {
+		return
+	}
+	for _, e := range p.Array {
+		rewriteOperatorsInPattern(e)
+	}
+	for _, kv := range p.Object {
+		rewriteOperatorsInString(kv.KeyString)
+		rewriteOperators(kv.KeyQuery)
+		rewriteOperatorsInPattern(kv.Val)
+	}
+}
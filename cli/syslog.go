@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitbears-dev/dq/builtin"
+	"github.com/pkg/errors"
+)
+
+type syslogParseError struct {
+	fname string
+	line  int
+	err   string
+}
+
+func (err *syslogParseError) Error() string {
+	return fmt.Sprintf("invalid syslog: %s:%d: %s", err.fname, err.line, err.err)
+}
+
+var syslogFacilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+var syslogSeverityNames = []string{
+	"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+}
+
+// parseSyslog parses a line of either RFC 5424 or BSD (RFC 3164) syslog.
+// The year of BSD syslog timestamps, which is missing, is inferred from now.
+func parseSyslog(line string, now time.Time) (map[string]interface{}, error) {
+	line = strings.TrimRight(line, "\r")
+	m := make(map[string]interface{})
+
+	if strings.HasPrefix(line, "<") {
+		end := strings.IndexByte(line, '>')
+		if end < 0 {
+			return nil, errors.New("unterminated PRI")
+		}
+		pri, err := strconv.Atoi(line[1:end])
+		if err != nil || pri < 0 || pri > 191 {
+			return nil, errors.Errorf("invalid PRI: %s", line[:end+1])
+		}
+		m["facility"] = pri / 8
+		m["facilityName"] = syslogFacilityNames[pri/8]
+		m["severity"] = pri % 8
+		m["severityName"] = syslogSeverityNames[pri%8]
+		line = line[end+1:]
+	}
+
+	if len(line) >= 2 && line[0] >= '1' && line[0] <= '9' && line[1] == ' ' {
+		return parseSyslog5424(m, line)
+	}
+	return parseSyslog3164(m, line, now)
+}
+
+func parseSyslog5424(m map[string]interface{}, line string) (map[string]interface{}, error) {
+	m["format"] = "rfc5424"
+	version, line, _ := strings.Cut(line, " ")
+	m["version"], _ = strconv.Atoi(version)
+
+	fields := make([]string, 5)
+	for i := range fields {
+		var ok bool
+		fields[i], line, ok = strings.Cut(line, " ")
+		if !ok && i < len(fields)-1 {
+			return nil, errors.New("insufficient header fields")
+		}
+	}
+
+	if fields[0] == "-" {
+		m["timestamp"] = nil
+	} else {
+		t, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return nil, errors.Errorf("invalid timestamp: %s", fields[0])
+		}
+		m["timestamp"] = builtin.EncapTime(t)
+	}
+	for i, key := range []string{"hostname", "appName", "procId", "msgId"} {
+		if v := fields[i+1]; v != "-" {
+			m[key] = v
+		} else {
+			m[key] = nil
+		}
+	}
+
+	sd, msg, err := parseSyslogStructuredData(line)
+	if err != nil {
+		return nil, err
+	}
+	m["structuredData"] = sd
+	m["message"] = strings.TrimPrefix(msg, "\ufeff")
+	return m, nil
+}
+
+// parseSyslogStructuredData parses STRUCTURED-DATA at the beginning of s, and
+// returns it and the rest of s (i.e. MSG).
+func parseSyslogStructuredData(s string) (interface{}, string, error) {
+	if s == "" {
+		return nil, "", nil
+	}
+	if s == "-" || strings.HasPrefix(s, "- ") {
+		return nil, strings.TrimPrefix(s[1:], " "), nil
+	}
+
+	sd := make(map[string]interface{})
+	for strings.HasPrefix(s, "[") {
+		end := strings.IndexAny(s, " ]")
+		if end < 0 {
+			return nil, "", errors.New("unterminated structured data")
+		}
+		params := make(map[string]interface{})
+		sd[s[1:end]] = params
+		s = s[end:]
+		for strings.HasPrefix(s, " ") {
+			name, rest, ok := strings.Cut(s[1:], `="`)
+			if !ok {
+				return nil, "", errors.New("invalid structured data parameter")
+			}
+			var value strings.Builder
+			escaped, closed := false, false
+			for i := 0; i < len(rest); i++ {
+				c := rest[i]
+				switch {
+				case escaped:
+					if c != '"' && c != '\\' && c != ']' {
+						value.WriteByte('\\')
+					}
+					value.WriteByte(c)
+					escaped = false
+				case c == '\\':
+					escaped = true
+				case c == '"':
+					s, closed = rest[i+1:], true
+				default:
+					value.WriteByte(c)
+				}
+				if closed {
+					break
+				}
+			}
+			if !closed {
+				return nil, "", errors.New("unterminated structured data parameter")
+			}
+			params[name] = value.String()
+		}
+		if !strings.HasPrefix(s, "]") {
+			return nil, "", errors.New("unterminated structured data")
+		}
+		s = s[1:]
+	}
+	return sd, strings.TrimPrefix(s, " "), nil
+}
+
+func parseSyslog3164(m map[string]interface{}, line string, now time.Time) (map[string]interface{}, error) {
+	m["format"] = "rfc3164"
+
+	var t time.Time
+	var dstStatus string
+	if len(line) > 0 && line[0] >= '0' && line[0] <= '9' {
+		// some daemons (e.g. rsyslog) write RFC 3339 timestamps instead
+		ts, rest, _ := strings.Cut(line, " ")
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, ts); err != nil {
+			return nil, errors.Errorf("invalid timestamp: %s", ts)
+		}
+		line = rest
+	} else {
+		if len(line) < len(time.Stamp) {
+			return nil, errors.Errorf("invalid timestamp: %s", line)
+		}
+		stamp, err := time.Parse(time.Stamp, line[:len(time.Stamp)])
+		if err != nil {
+			return nil, errors.Errorf("invalid timestamp: %s", line[:len(time.Stamp)])
+		}
+		line = line[len(time.Stamp):]
+		nsec := 0
+		if strings.HasPrefix(line, ".") {
+			n := 1
+			for n < len(line) && line[n] >= '0' && line[n] <= '9' {
+				n++
+			}
+			frac, _ := strconv.ParseFloat("0"+line[:n], 64)
+			nsec = int(frac * 1e9)
+			line = line[n:]
+		}
+		var ok bool
+		t, dstStatus, ok = builtin.DateInInferredYear(stamp.Month(), stamp.Day(), stamp.Hour(), stamp.Minute(), stamp.Second(), nsec, builtin.AssumedLocation(), now)
+		if !ok {
+			return nil, errors.Errorf("invalid timestamp: %s", stamp.Format(time.Stamp))
+		}
+		line = strings.TrimPrefix(line, " ")
+	}
+	m["timestamp"] = builtin.EncapLocalTime(t, dstStatus)
+
+	hostname, rest, _ := strings.Cut(line, " ")
+	m["hostname"] = hostname
+
+	m["appName"], m["procId"] = nil, nil
+	if i := strings.IndexAny(rest, "[: "); i > 0 {
+		tag := rest[:i]
+		switch {
+		case rest[i] == '[':
+			if end := strings.Index(rest[i:], "]:"); end > 0 {
+				m["appName"], m["procId"] = tag, rest[i+1:i+end]
+				rest = rest[i+end+2:]
+			}
+		case rest[i] == ':':
+			m["appName"] = tag
+			rest = rest[i+1:]
+		}
+	}
+	m["message"] = strings.TrimPrefix(rest, " ")
+	return m, nil
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"time"
+
+	"github.com/bitbears-dev/dq/builtin"
+	"github.com/pkg/errors"
+)
+
+// timeOutputFormatter converts a time or duration object into a scalar value
+// to be output instead of the object. It returns false for other values.
+type timeOutputFormatter func(interface{}) (interface{}, bool)
+
+func newTimeOutputFormatter(format, layout string) (timeOutputFormatter, error) {
+	if format == "" {
+		if layout != "" {
+			format = "layout"
+		} else {
+			return nil, nil
+		}
+	}
+
+	var formatTime func(time.Time) interface{}
+	var formatDuration func(time.Duration) interface{}
+	switch format {
+	case "rfc3339":
+		formatTime = func(t time.Time) interface{} { return t.Format(time.RFC3339) }
+	case "rfc3339nano":
+		formatTime = func(t time.Time) interface{} { return t.Format(time.RFC3339Nano) }
+	case "layout":
+		if layout == "" {
+			return nil, errors.New("--time-layout is required for --time-output=layout")
+		}
+		formatTime = func(t time.Time) interface{} { return t.Format(layout) }
+	case "unix":
+		formatTime = func(t time.Time) interface{} { return t.Unix() }
+		formatDuration = func(d time.Duration) interface{} {
+			// an integer as the other units, unless it has a fraction
+			if d%time.Second == 0 {
+				return int64(d / time.Second)
+			}
+			return d.Seconds()
+		}
+	case "unixmilli":
+		formatTime = func(t time.Time) interface{} { return t.UnixMilli() }
+		formatDuration = func(d time.Duration) interface{} { return d.Milliseconds() }
+	case "unixmicro":
+		formatTime = func(t time.Time) interface{} { return t.UnixMicro() }
+		formatDuration = func(d time.Duration) interface{} { return d.Microseconds() }
+	case "unixnano":
+		formatTime = func(t time.Time) interface{} { return t.UnixNano() }
+		formatDuration = func(d time.Duration) interface{} { return d.Nanoseconds() }
+	default:
+		return nil, errors.Errorf("unknown time output format: %s", format)
+	}
+	if formatDuration == nil {
+		formatDuration = func(d time.Duration) interface{} { return d.String() }
+	}
+
+	return func(v interface{}) (interface{}, bool) {
+		if t, ok := builtin.DecapTime(v); ok {
+			return formatTime(*t), true
+		}
+		if d, ok := builtin.DecapDuration(v); ok {
+			return formatDuration(*d), true
+		}
+		return nil, false
+	}, nil
+}
@@ -3,11 +3,14 @@ module github.com/bitbears-dev/dq
 go 1.23.0
 
 require (
-	github.com/itchyny/gojq v0.12.9 // indirect
+	github.com/itchyny/gojq v0.12.9
+	github.com/jessevdk/go-flags v1.5.0
+	github.com/mattn/go-runewidth v0.0.14
+	github.com/pkg/errors v0.9.1
+)
+
+require (
 	github.com/itchyny/timefmt-go v0.1.4 // indirect
-	github.com/jessevdk/go-flags v1.5.0 // indirect
-	github.com/mattn/go-runewidth v0.0.14 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	golang.org/x/sys v0.0.0-20220829200755-d48e67d00261 // indirect
 )
@@ -4,6 +4,7 @@ set -Eeuo pipefail
 d="$( cd "$( dirname "$0" )"; cd ..; pwd -P )"
 
 bin=${DQ_BIN:-"$d/cmd/dq/dq"}
+tzdata_zip="$d/builtin/tzdata/zoneinfo.zip"
 ls -l "$bin"
 
 RESET=$'\e[0m'
@@ -697,6 +698,1105 @@ dq_regression_test_for_utc_unix_strftime() {
   print_ok
 }
 
+dq_supports_find_times_filter() {
+  progress "dq supports find_times() filter"
+  line='Oct 18 08:02:11 host app[123]: [18/Oct/2026:08:02:11 +0900] at=2022-10-23T23:03:01+09:00 ts=1666533582'
+  result="$( echo "$line" | $bin -R -c '[find_times | .match.format]' )"
+  assert_eq "$result" '["syslog","clf","rfc3339","unix"]'
+  result="$( echo "$line" | $bin -R -c '[find_times | .unix] | .[1:]' )"
+  assert_eq "$result" '[1792278131,1666533781,1666533582]'
+  result="$( $bin -c 'find_times("ts=1666533582694") | .match | [.start, .end, .text]' )"
+  assert_eq "$result" '[3,16,"1666533582694"]'
+  result="$( echo 'at 2026-10-18 08:02:11' | TZ=Asia/Tokyo $bin -R -c '[(find_times | .rfc3339), ("2026-10-18 08:02:11" | guess | .rfc3339)]' )"
+  assert_eq "$result" '["2026-10-18T08:02:11Z","2026-10-18T08:02:11Z"]'
+  result="$( echo 'at 2026-10-18 08:02:11' | $bin -R -c --assume-tz Asia/Tokyo '[(find_times | .rfc3339), ("2026-10-18 08:02:11" | guess | .rfc3339)]' )"
+  assert_eq "$result" '["2026-10-18T08:02:11+09:00","2026-10-18T08:02:11+09:00"]'
+  result="$( echo 'Oct 18 08:02:11 host app: hi' | $bin -R -c --assume-tz Asia/Tokyo 'find_times | .timezone.offsetSeconds' )"
+  assert_eq "$result" '32400'
+  print_ok
+}
+
+dq_supports_extract_times_option() {
+  progress "dq supports --extract-times option"
+  result="$( printf 'no time here\nts=1666533582 at=2022-10-23T23:03:01+09:00\n' | $bin --extract-times -c '[.match.line, .unix]' )"
+  assert_eq "$result" $'[2,1666533582]\n[2,1666533781]'
+  print_ok
+}
+
+dq_supports_logfmt_option() {
+  progress "dq supports --logfmt option"
+  result="$( printf 'ts=2022-10-23T23:03:01+09:00 level=info msg="hello \\"world\\"" debug\n\nlevel=warn\n' | $bin --logfmt -c . )"
+  assert_eq "$result" $'{"debug":true,"level":"info","msg":"hello \\"world\\"","ts":"2022-10-23T23:03:01+09:00"}\n{"level":"warn"}'
+  print_ok
+}
+
+dq_supports_time_key_option() {
+  progress "dq supports --time-key option"
+  result="$( echo 'ts=2022-10-23T23:03:01+09:00 at=1666533582 level=info' | $bin --logfmt --time-key ts --time-key at -c '[.ts.unix, .at.unix, .level]' )"
+  assert_eq "$result" '[1666533781,1666533582,"info"]'
+  result="$( echo '{"ts":"2022-10-23T23:03:01+09:00"}' | $bin --time-key ts . 2>&1 || true )"
+  assert_eq "$result" '--logfmt is required for --time-key'
+  print_ok
+}
+
+dq_supports_syslog_option_for_rfc5424() {
+  progress "dq supports --syslog option for RFC 5424"
+  line='<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event log entry'
+  result="$( echo "$line" | $bin --syslog -c '[.facilityName, .severityName, .timestamp.unixMilli, .hostname, .appName, .procId, .msgId, .structuredData, .message]' )"
+  assert_eq "$result" '["local4","notice",1065910455003,"mymachine.example.com","evntslog",null,"ID47",{"exampleSDID@32473":{"eventSource":"Application","iut":"3"}},"An application event log entry"]'
+  print_ok
+}
+
+dq_supports_syslog_option_for_rfc3164() {
+  progress "dq supports --syslog option for RFC 3164"
+  result="$( echo '<34>Oct 11 22:14:15 mymachine su[123]: failed for lonvick' | $bin --syslog -c '[.facility, .severity, .timestamp.month, .timestamp.day, .timestamp.hour, .hostname, .appName, .procId, .message]' )"
+  assert_eq "$result" '[4,2,10,11,22,"mymachine","su","123","failed for lonvick"]'
+  result="$( echo '<34>Oct 11 22:14:15 mymachine su[123]: failed' | $bin --syslog --assume-tz Asia/Tokyo -c '.timestamp | [.hour, .timezone.offsetSeconds]' )"
+  assert_eq "$result" '[22,32400]'
+  # the year is inferred so that the timestamp is not in the future
+  result="$( echo 'Dec 31 23:59:59 host kernel: bye' | $bin --syslog '.timestamp.unix' )"
+  now="$( date +%s )"
+  if [ "$result" -gt $(( now + 7 * 86400 )) ] || [ "$result" -lt $(( now - 366 * 86400 )) ]; then
+    fail_with_message "unexpected year is inferred: $result"
+  fi
+  print_ok
+}
+
+dq_supports_decorate_times_option() {
+  progress "dq supports --decorate-times option"
+  input='{"id":1666533582,"events":[{"created_at":"2022-10-23T23:03:01+09:00","code":"1666533582"}]}'
+  result="$( echo "$input" | $bin --decorate-times -c '[.id, .events[].created_at.weekday.name, .events[].code]' )"
+  assert_eq "$result" '[1666533582,"Sunday","1666533582"]'
+  print_ok
+}
+
+dq_supports_decorate_key_option() {
+  progress "dq supports --decorate-key option"
+  input='{"id":1666533582,"updated_at":1666533582,"events":[{"created_at":"2022-10-23T23:03:01+09:00"}]}'
+  result="$( echo "$input" | $bin --decorate-key '_at$' -c '[.id, .updated_at.unix, .events[].created_at.unix]' )"
+  assert_eq "$result" '[1666533582,1666533582,1666533781]'
+  print_ok
+}
+
+dq_supports_decorate_path_option() {
+  progress "dq supports --decorate-path option"
+  input='{"at":1666533582,"events":[{"at":1666533582}]}'
+  result="$( echo "$input" | $bin --decorate-path 'events.*.at' -c '[.at, .events[].at.unix]' )"
+  assert_eq "$result" '[1666533582,1666533582]'
+  result="$( echo "$input" | $bin --decorate-path '**.at' -c '[.at.unix, .events[].at.unix]' )"
+  assert_eq "$result" '[1666533582,1666533582]'
+  print_ok
+}
+
+dq_supports_time_output_option() {
+  progress "dq supports --time-output option"
+  input='{"at":"2022-10-23T23:03:01.5+09:00","n":1}'
+  result="$( echo "$input" | $bin --decorate-times --time-output rfc3339 -c '.d = (3 | hours)' )"
+  assert_eq "$result" '{"at":"2022-10-23T23:03:01+09:00","d":"3h0m0s","n":1}'
+  result="$( echo "$input" | $bin --decorate-times --time-output rfc3339nano -c '.' )"
+  assert_eq "$result" '{"at":"2022-10-23T23:03:01.5+09:00","n":1}'
+  result="$( echo "$input" | $bin --decorate-times --time-output unix -c '.d = (3 | hours)' )"
+  assert_eq "$result" '{"at":1666533781,"d":10800,"n":1}'
+  result="$( $bin --time-output unix -c '[(3 | hours), (1.5 | seconds)]' )"
+  assert_eq "$result" '[10800,1.5]'
+  result="$( echo "$input" | $bin --decorate-times --time-output unixmilli -c '.d = (3 | hours)' )"
+  assert_eq "$result" '{"at":1666533781500,"d":10800000,"n":1}'
+  result="$( echo "$input" | $bin --decorate-times --time-output rfc3339 -r '.at' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( echo "$input" | $bin --decorate-times --time-output rfc3339 -c '.at' | $bin --decorate-times -r '.rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  print_ok
+}
+
+dq_supports_time_layout_option() {
+  progress "dq supports --time-layout option"
+  result="$( echo '"2022-10-23T23:03:01+09:00"' | $bin --time-output layout --time-layout '2006/01/02 15:04' -r 'fromrfc3339' )"
+  assert_eq "$result" '2022/10/23 23:03'
+  result="$( echo '"2022-10-23T23:03:01+09:00"' | $bin --time-layout '2006/01/02' -c '[fromrfc3339]' )"
+  assert_eq "$result" '["2022/10/23"]'
+  print_ok
+}
+
+dq_time_objects_survive_json_builtins() {
+  progress "dq time objects survive tojson / fromjson, tostring and @json"
+  t='fromrfc3339("2022-10-23T23:03:01.5+09:00")'
+  result="$( $bin -r "$t | tojson | fromjson | .rfc3339" )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( $bin -r "$t | tostring | fromjson | add_date(0; 0; 1) | .rfc3339" )"
+  assert_eq "$result" '2022-10-24T23:03:01+09:00'
+  result="$( $bin -r "$t | @json | fromjson | .nanosecond" )"
+  assert_eq "$result" '500000000'
+  result="$( $bin -c "$t | tojson | fromjson | .__dq__source" )"
+  assert_eq "$result" '["time",1666533781,500000000,"",32400]'
+  result="$( $bin -c '3 | hours | tojson | fromjson | .minutes' )"
+  assert_eq "$result" '180'
+  print_ok
+}
+
+dq_time_objects_survive_encoding_builtins() {
+  progress "dq time objects survive @base64 / @base64d"
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:01+09:00") | @base64 | @base64d | fromjson | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  print_ok
+}
+
+dq_time_objects_survive_object_builtins() {
+  progress "dq time objects survive to_entries / from_entries, with_entries and walk"
+  t='fromrfc3339("2022-10-23T23:03:01+09:00")'
+  result="$( $bin -r "$t | to_entries | from_entries | .rfc3339" )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( $bin -r "$t | with_entries(.) | add_date(0; 1; 0) | .rfc3339" )"
+  assert_eq "$result" '2022-11-23T23:03:01+09:00'
+  result="$( $bin -r "{a: [$t]} | walk(.) | .a[0].rfc3339" )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  print_ok
+}
+
+dq_time_objects_can_be_compared() {
+  progress "dq time objects can be compared with =="
+  result="$( $bin -c '[fromunix(1666533781) == fromunix(1666533781), fromunix(1666533781) == fromunix(1666533782), (1 | hours) == (60 | minutes)]' )"
+  assert_eq "$result" '[true,false,true]'
+  print_ok
+}
+
+dq_sorts_times_by_instant() {
+  progress "dq sorts times by instant"
+  input='["2022-10-23T23:03:01+09:00","2022-10-23T13:03:00Z","2022-10-23T14:03:02Z"]'
+  result="$( echo "$input" | $bin -c 'map(guess) | sort | map(.rfc3339)' )"
+  assert_eq "$result" '["2022-10-23T13:03:00Z","2022-10-23T23:03:01+09:00","2022-10-23T14:03:02Z"]'
+  result="$( echo "$input" | $bin -r '[.[] | guess] | sort | first | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T13:03:00Z'
+  result="$( echo "$input" | $bin -c 'map({at: guess}) | sort_by(.at) | map(.at.unix)' )"
+  assert_eq "$result" '[1666530180,1666533781,1666533782]'
+  result="$( echo "$input" | $bin -c 'map(guess) | [min.unix, max.unix]' )"
+  assert_eq "$result" '[1666530180,1666533782]'
+  result="$( echo "$input" | $bin -c 'map({at: guess}) | [min_by(.at).at.unix, max_by(.at).at.unix]' )"
+  assert_eq "$result" '[1666530180,1666533782]'
+  result="$( $bin -c '[(2 | hours), (30 | minutes)] | sort | map(.minutes)' )"
+  assert_eq "$result" '[30,120]'
+  result="$( $bin -c '[3, 1, 2] | [sort, min, max]' )"
+  assert_eq "$result" '[[1,2,3],1,3]'
+  print_ok
+}
+
+dq_regards_same_instants_as_equal_in_unique_and_group_by() {
+  progress "dq regards same instants as equal in unique and group_by"
+  input='["2022-10-23T23:03:01+09:00","2022-10-23T14:03:01Z","2022-10-24T00:00:00Z"]'
+  result="$( echo "$input" | $bin -c 'map(guess) | unique | map(.unix)' )"
+  assert_eq "$result" '[1666533781,1666569600]'
+  result="$( echo "$input" | $bin -c 'map({at: guess}) | unique_by(.at) | length' )"
+  assert_eq "$result" '2'
+  result="$( echo "$input" | $bin -c 'map(guess) | group_by(.) | map(length)' )"
+  assert_eq "$result" '[2,1]'
+  result="$( echo "$input" | $bin -c 'map(guess) | [[.[0]] == [.[1]], {at: .[0]} == {at: .[1]}, [.[0]] != [.[1]], [.[0]] == [.[2]]]' )"
+  assert_eq "$result" '[true,true,false,false]'
+  print_ok
+}
+
+dq_supports_before_after_between_and_same_instant_filters() {
+  progress "dq supports before(), after(), between() and same_instant() filters"
+  result="$( $bin -c 'fromunix(1666533781) | [before(fromunix(1666533782)), before(fromunix(1666533781)), after(fromunix(1666533780)), after(fromunix(1666533781))]' )"
+  assert_eq "$result" '[true,false,true,false]'
+  result="$( $bin -c 'fromunix(1666533781) | [between(fromunix(1666533781); fromunix(1666533782)), between(fromunix(1666533780); fromunix(1666533781))]' )"
+  assert_eq "$result" '[true,false]'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01+09:00") | [same_instant(fromrfc3339("2022-10-23T14:03:01Z")), same_instant(fromrfc3339("2022-10-23T23:03:01Z"))]' )"
+  assert_eq "$result" '[true,false]'
+  print_ok
+}
+
+dq_supports_arithmetic_operators_on_times_and_durations() {
+  progress "dq supports arithmetic operators on times and durations"
+  t='fromrfc3339("2022-10-23T23:03:01+09:00")'
+  result="$( $bin -r "$t + (3 | hours) | .rfc3339" )"
+  assert_eq "$result" '2022-10-24T02:03:01+09:00'
+  result="$( $bin -r "(3 | hours) + $t | .rfc3339" )"
+  assert_eq "$result" '2022-10-24T02:03:01+09:00'
+  result="$( $bin -r "$t - (3 | hours) | .rfc3339" )"
+  assert_eq "$result" '2022-10-23T20:03:01+09:00'
+  result="$( $bin -c "$t - fromrfc3339(\"2022-10-23T12:03:01Z\") | .hours" )"
+  assert_eq "$result" '2'
+  result="$( $bin -c '[(2 | hours) * 3, 3 * (2 | hours), (2 | hours) / 4, (2 | hours) + (30 | minutes), (2 | hours) - (30 | minutes)] | map(.minutes)' )"
+  assert_eq "$result" '[360,360,30,150,90]'
+  result="$( $bin -c '(2 | hours) / (1 | minutes)' )"
+  assert_eq "$result" '120'
+  result="$( $bin -c '[(1 | hours), (30 | minutes)] | add | .minutes' )"
+  assert_eq "$result" '90'
+  result="$( $bin -c "{at: $t} | .at += (1 | hours) | .at.hour" )"
+  assert_eq "$result" '0'
+  result="$( $bin -c "$t | . + {label: \"x\"} | [.label, .rfc3339]" )"
+  assert_eq "$result" '["x","2022-10-23T23:03:01+09:00"]'
+  result="$( $bin -c '[1 + 2, 7 / 2, ("a" + "b"), ({a: 1} * {b: 2}), ([1, 2] - [1])]' )"
+  assert_eq "$result" '[3,3.5,"ab",{"a":1,"b":2},[2]]'
+  print_ok
+}
+
+dq_reports_meaningless_arithmetic_on_times() {
+  progress "dq reports meaningless arithmetic on times"
+  result="$( $bin 'fromunix(0) + fromunix(0)' 2>&1 || true )"
+  assert_eq "$result" 'cannot add: time and time'
+  result="$( $bin '(1 | hours) - fromunix(0)' 2>&1 || true )"
+  assert_eq "$result" 'cannot subtract: duration and time'
+  result="$( $bin 'fromunix(0) * 2' 2>&1 || true )"
+  assert_eq "$result" 'cannot multiply: time and number'
+  result="$( $bin '(1 | hours) / 0' 2>&1 || true )"
+  assert_eq "$result" 'cannot divide duration by zero'
+  result="$( $bin 'fromunix(0) < (1 | hours)' 2>&1 || true )"
+  assert_eq "$result" 'cannot compare: time and duration'
+  result="$( $bin '(106751 | days) + (106751 | days)' 2>&1 || true )"
+  assert_eq "$result" 'duration out of range'
+  result="$( $bin -- '-(106751 | days) - (106751 | days)' 2>&1 || true )"
+  assert_eq "$result" 'duration out of range'
+  print_ok
+}
+
+dq_supports_comparison_operators_on_times_and_durations() {
+  progress "dq supports comparison operators on times and durations"
+  result="$( $bin -c '[fromunix(1) > fromunix(0), fromunix(1) < fromunix(0), fromunix(1) >= fromunix(1), fromunix(1) <= fromunix(0)]' )"
+  assert_eq "$result" '[true,false,true,false]'
+  result="$( $bin -c '[fromrfc3339("2022-10-23T23:03:01+09:00") == fromrfc3339("2022-10-23T14:03:01Z"), fromunix(0) != fromunix(1), fromunix(0) == null]' )"
+  assert_eq "$result" '[true,true,false]'
+  result="$( $bin -c '[(90 | minutes) > (1 | hours), (60 | minutes) == (1 | hours)]' )"
+  assert_eq "$result" '[true,true]'
+  result="$( echo '[{"x":1},{"x":2}]' | $bin -c 'del(.[] | select(.x > 1))' )"
+  assert_eq "$result" '[{"x":1}]'
+  print_ok
+}
+
+dq_supports_negation_and_abs_of_durations() {
+  progress "dq supports negation and abs of durations"
+  result="$( $bin -c -- '[-(3 | hours), (3 | hours | negate), (-(3 | hours) | abs)] | map(.hours)' )"
+  assert_eq "$result" '[-3,-3,3]'
+  result="$( echo '[5]' | $bin -c -- '[-1, -.[0], (-3 | abs)]' )"
+  assert_eq "$result" '[-1,-5,3]'
+  print_ok
+}
+
+dq_supports_adding_and_scaling_durations() {
+  progress "dq supports adding and scaling durations"
+  result="$( $bin -c '[(1 | hours | add(30 | minutes)), (1 | hours | scale(2.5))] | map(.minutes)' )"
+  assert_eq "$result" '[90,150]'
+  result="$( $bin '106751 | days | add(106751 | days)' 2>&1 || true )"
+  assert_eq "$result" 'duration out of range'
+  print_ok
+}
+
+dq_supports_truncate_and_round_filters() {
+  progress "dq supports truncate() and round() filters"
+  result="$( $bin -c '95 | minutes | [truncate(1 | hours), round(1 | hours)] | map(.minutes)' )"
+  assert_eq "$result" '[60,120]'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:31+09:00") | round(1 | minutes) | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:04:00+09:00'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:31+09:00") | truncate(1 | minutes) | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:00+09:00'
+  print_ok
+}
+
+dq_supports_fractional_durations_and_weeks_and_days() {
+  progress "dq supports fractional durations, weeks() and days()"
+  result="$( $bin -c '[(1.5 | hours | .minutes), (0.5 | seconds | .milliseconds), (2 | weeks | .hours), (1.5 | days | .hours)]' )"
+  assert_eq "$result" '[90,500,336,36]'
+  print_ok
+}
+
+dq_supports_components_of_durations() {
+  progress "dq supports components of durations"
+  result="$( $bin -c '(26 | hours) + (3 | minutes) + (4 | seconds) + (5 | nanoseconds) | .components | [.negative, .days, .hours, .minutes, .seconds, .nanoseconds]' )"
+  assert_eq "$result" '[false,1,2,3,4,5]'
+  result="$( $bin -c -- '-(26 | hours) | .components | [.negative, .days, .hours]' )"
+  assert_eq "$result" '[true,1,2]'
+  print_ok
+}
+
+dq_supports_diff_filter() {
+  progress "dq supports diff() filter"
+  result="$( $bin -c 'from_ymd(2024;9;19) | diff(from_ymd(2023;7;16)) | [.negative, .years, .months, .days, .iso8601]' )"
+  assert_eq "$result" '[false,1,2,3,"P1Y2M3D"]'
+  result="$( $bin -c 'from_ymd(2023;7;16) | diff(from_ymd(2024;9;19)) | [.negative, .years, .iso8601]' )"
+  assert_eq "$result" '[true,1,"-P1Y2M3D"]'
+  result="$( $bin -c 'fromrfc3339("2024-01-01T10:20:30.5Z") | diff(fromrfc3339("2023-12-31T09:00:00Z")) | [.days, .hours, .minutes, .seconds, .nanoseconds, .iso8601]' )"
+  assert_eq "$result" '[1,1,20,30,500000000,"P1DT1H20M30.5S"]'
+  result="$( $bin -r 'from_ymd(1500;1;1) | diff(from_ymd(2024;1;1)) | .iso8601' )"
+  assert_eq "$result" '-P524Y'
+  print_ok
+}
+
+dq_supports_end_of_month_options_of_diff_filter() {
+  progress "dq supports endOfMonth options of diff() filter"
+  result="$( $bin -c 'from_ymd(2023;2;28) | [diff(from_ymd(2023;1;31)), diff(from_ymd(2023;1;31); {endOfMonth: "overflow"})] | map([.months, .days])' )"
+  assert_eq "$result" '[[1,0],[0,28]]'
+  result="$( $bin -c 'from_ymd(2023;3;31) | [diff(from_ymd(2023;2;28)), diff(from_ymd(2023;2;28); {endOfMonth: "preserve"})] | map([.months, .days])' )"
+  assert_eq "$result" '[[1,3],[1,0]]'
+  result="$( $bin 'fromunix(0) | diff(fromunix(0); {endOfMonth: "x"})' 2>&1 || true )"
+  assert_eq "$result" 'unknown endOfMonth: x (expected clamp, overflow or preserve)'
+  print_ok
+}
+
+dq_supports_age_filter() {
+  progress "dq supports age() filter"
+  result="$( $bin -c 'from_ymd(1990;2;28) | [age(from_ymd(2024;2;27)), age(from_ymd(2024;2;28))]' )"
+  assert_eq "$result" '[33,34]'
+  result="$( $bin -c 'from_ymd(2000;2;29) | [age(from_ymd(2023;2;27)), age(from_ymd(2023;2;28))]' )"
+  assert_eq "$result" '[22,23]'
+  result="$( $bin -c 'today | age' )"
+  assert_eq "$result" '0'
+  print_ok
+}
+
+dq_supports_humanize_filter_for_durations() {
+  progress "dq supports humanize() filter for durations"
+  d='(2 | hours) + (4 | minutes) + (10 | seconds)'
+  result="$( $bin -c "$d | [humanize, humanize({style: \"short\"}), humanize({style: \"long\"}), humanize({style: \"long\", precision: 2})]" )"
+  assert_eq "$result" '["about 2 hours","2h 4m 10s","2 hours, 4 minutes, 10 seconds","2 hours, 4 minutes"]'
+  result="$( $bin -c '[(30 | seconds), (59 | minutes) + (50 | seconds), (45 | days), (0 | seconds), (250 | milliseconds), -(3 | hours)] | map(humanize)' )"
+  assert_eq "$result" '["30 seconds","about 1 hour","about 2 months","0 seconds","less than a second","-3 hours"]'
+  result="$( $bin -c '(-9223372036854775807 | nanoseconds) - (1 | nanoseconds) | [humanize, humanize({style: "short"})]' )"
+  assert_eq "$result" '["-about 292 years","-106751d 23h 47m 16s 854ms"]'
+  result="$( $bin -c "$d | [humanize({lang: \"ja\"}), humanize({lang: \"ja\", style: \"short\"})]" )"
+  assert_eq "$result" '["約2時間","2時間4分10秒"]'
+  print_ok
+}
+
+dq_supports_humanize_filter_for_times() {
+  progress "dq supports humanize() filter for times"
+  result="$( $bin -c 'fromunix(1666533781) as $ref | [(fromunix(1666523000) | humanize($ref)), (fromunix(1666706600) | humanize($ref)), ($ref | humanize($ref))]' )"
+  assert_eq "$result" '["3 hours ago","in 2 days","just now"]'
+  result="$( $bin -c 'fromunix(1666533781) as $ref | [(fromunix(1666523000) | humanize($ref; {lang: "ja"})), (fromunix(1666706600) | humanize($ref; {lang: "ja"}))]' )"
+  assert_eq "$result" '["3時間前","2日後"]'
+  result="$( $bin -c 'fromunix(1666533781) as $ref | fromunix(1666523000) | humanize($ref; {style: "long", precision: 2})' )"
+  assert_eq "$result" '"2 hours, 59 minutes ago"'
+  result="$( $bin -r 'now | fromunix | humanize' )"
+  assert_eq "$result" 'just now'
+  result="$( $bin '1 | hours | humanize({lang: "xx"})' 2>&1 || true )"
+  assert_eq "$result" 'unsupported language: xx'
+  print_ok
+}
+
+dq_supports_locale_option() {
+  progress "dq supports --locale option"
+  t='fromrfc3339("2022-10-23T23:03:01+09:00")'
+  result="$( $bin --locale ja -c "$t | [.weekday.localName, .monthLocalName, .weekday.name, .monthName]" )"
+  assert_eq "$result" '["日曜日","10月","Sunday","October"]'
+  result="$( $bin --locale pt_BR.UTF-8 -c "$t | [.weekday.localName, .monthLocalName]" )"
+  assert_eq "$result" '["domingo","outubro"]'
+  result="$( $bin --locale ja -r '1 | hours | humanize' )"
+  assert_eq "$result" '1時間'
+  result="$( $bin --locale xx . 2>&1 || true )"
+  assert_eq "$result" 'unsupported locale: xx'
+  print_ok
+}
+
+dq_takes_locale_from_environment_variables() {
+  progress "dq takes locale from environment variables"
+  t='fromrfc3339("2022-10-23T23:03:01+09:00")'
+  result="$( LC_ALL= LC_TIME= LANG=de_DE.UTF-8 $bin -c "$t | [.weekday.localName, .monthLocalName]" )"
+  assert_eq "$result" '["Sonntag","Oktober"]'
+  result="$( LC_ALL= LC_TIME=fr_FR.UTF-8 LANG=de_DE.UTF-8 $bin -c "$t | [.weekday.localName, .monthLocalName]" )"
+  assert_eq "$result" '["dimanche","octobre"]'
+  result="$( LC_ALL= LC_TIME= LANG=xx_XX.UTF-8 $bin -c "$t | [.weekday.localName, .monthLocalName]" )"
+  assert_eq "$result" '["Sunday","October"]'
+  result="$( LC_ALL=ja_JP.UTF-8 $bin -c "$t | [.weekday.name, .monthName, (1 | hours | humanize)]" )"
+  assert_eq "$result" '["Sunday","October","1 hour"]'
+  print_ok
+}
+
+dq_supports_format_locale_filter() {
+  progress "dq supports format_locale() filter"
+  t='fromrfc3339("2022-10-23T23:03:01+09:00")'
+  result="$( $bin -r "$t | format_locale(\"de\"; \"Monday, 2. January 2006 (Mon, Jan)\")" )"
+  assert_eq "$result" 'Sonntag, 23. Oktober 2022 (So., Okt.)'
+  result="$( $bin -r "$t | format_locale(\"ja\"; \"2006年1月2日 (Mon) PM3:04\")" )"
+  assert_eq "$result" '2022年10月23日 (日) 午後11:03'
+  result="$( $bin -r "$t | format_locale(\"en\"; \"Mon Jan 2 15:04:05 2006\")" )"
+  assert_eq "$result" 'Sun Oct 23 23:03:01 2022'
+  result="$( $bin -r "$t | format_locale(\"en\"; \"3:04pm\"), format_locale(\"en\"; \"3:04PM\")" )"
+  assert_eq "$result" '11:03pm
+11:03PM'
+  print_ok
+}
+
+dq_supports_to_wareki_filter() {
+  progress "dq supports to_wareki() filter"
+  result="$( $bin -r 'from_ymd(2026;10;18) | to_wareki' )"
+  assert_eq "$result" '令和8年10月18日'
+  result="$( $bin -r 'from_ymd(2019;5;1) | to_wareki' )"
+  assert_eq "$result" '令和元年5月1日'
+  result="$( $bin -r 'from_ymd(2019;5;1) | to_wareki({gannen: false})' )"
+  assert_eq "$result" '令和1年5月1日'
+  result="$( $bin -r 'from_ymd(2019;4;30) | to_wareki({style: "abbr"})' )"
+  assert_eq "$result" 'H31.04.30'
+  result="$( $bin -r 'from_ymd(1989;1;7) | to_wareki({style: "romaji"})' )"
+  assert_eq "$result" 'Showa 64.1.7'
+  result="$( $bin -r -c '[from_ymd(1868;10;23), from_ymd(1912;7;29), from_ymd(1912;7;30), from_ymd(1926;12;25), from_ymd(1989;1;8)] | map(to_wareki)' )"
+  assert_eq "$result" '["明治元年10月23日","明治45年7月29日","大正元年7月30日","昭和元年12月25日","平成元年1月8日"]'
+  result="$( $bin 'from_ymd(1868;10;22) | to_wareki' 2>&1 || true )"
+  assert_eq "$result" 'no Japanese era before Meiji 1868-10-23'
+  print_ok
+}
+
+dq_supports_from_wareki_filter() {
+  progress "dq supports from_wareki() filter"
+  result="$( TZ=Asia/Tokyo $bin -r '"令和8年10月18日" | from_wareki | .rfc3339' )"
+  assert_eq "$result" '2026-10-18T00:00:00+09:00'
+  result="$( $bin -c '["令和元年5月1日", "㋿元年5月1日", "令和１年５月１日", "Reiwa 1.5.1", "reiwa 1/5/1", "R1.05.01"] | map(from_wareki | [.year, .month, .day]) | unique' )"
+  assert_eq "$result" '[[2019,5,1]]'
+  result="$( $bin -c 'from_wareki("Shōwa 64-1-7") | [.year, .month, .day]' )"
+  assert_eq "$result" '[1989,1,7]'
+  result="$( $bin '"平成31年5月1日" | from_wareki' 2>&1 || true )"
+  assert_eq "$result" 'no such date in Heisei (平成): 平成31年5月1日'
+  result="$( $bin '"令和2年2月30日" | from_wareki' 2>&1 || true )"
+  assert_eq "$result" 'no such date: 令和2年2月30日'
+  result="$( $bin '"2019-05-01" | from_wareki' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as Japanese era: 2019-05-01'
+  print_ok
+}
+
+dq_supports_era_option() {
+  progress "dq supports --era option"
+  result="$( $bin -c --era 'from_ymd(2026;10;18) | .era' )"
+  assert_eq "$result" '{"abbr":"R","name":"令和","romaji":"Reiwa","start":"2019-05-01","year":8}'
+  result="$( $bin -c --era 'from_ymd(1868;1;1) | .era' )"
+  assert_eq "$result" 'null'
+  result="$( $bin -c 'from_ymd(2026;10;18) | has("era")' )"
+  assert_eq "$result" 'false'
+  print_ok
+}
+
+dq_supports_to_calendar_filter() {
+  progress "dq supports to_calendar() filter"
+  result="$( $bin -c 'from_ymd(2025;3;1) | to_calendar("islamic") | [.calendar, .year, .month, .day, .monthName, .leapYear]' )"
+  assert_eq "$result" '["islamic",1446,9,1,"Ramadan",false]'
+  result="$( $bin -c 'from_ymd(2024;10;3) | to_calendar("hebrew") | [.year, .month, .monthCode, .day, .monthName, .daysInYear]' )"
+  assert_eq "$result" '[5785,1,"M01",1,"Tishrei",355]'
+  result="$( $bin -c '[from_ymd(2024;2;23), from_ymd(2024;3;24)] | map(to_calendar("hebrew") | [.month, .monthCode, .day, .monthName, .leapMonth])' )"
+  assert_eq "$result" '[[6,"M05L",14,"Adar I",true],[7,"M06",14,"Adar II",false]]'
+  result="$( $bin -c '[from_ymd(2025;3;20), from_ymd(2025;3;21)] | map(to_calendar("jalali") | [.calendar, .year, .month, .day, .monthName, .leapYear])' )"
+  assert_eq "$result" '[["persian",1403,12,30,"Esfand",true],["persian",1404,1,1,"Farvardin",false]]'
+  result="$( $bin -c 'from_ymd(2025;8;1) | to_calendar("chinese") | [.year, .month, .monthCode, .day, .monthName, .leapMonth, .monthsInYear, .yearName, .zodiac]' )"
+  assert_eq "$result" '[2025,7,"M06L",8,"闰六月",true,13,"乙巳","Snake"]'
+  result="$( $bin 'from_ymd(2025;1;1) | to_calendar("mayan")' 2>&1 || true )"
+  assert_eq "$result" 'unsupported calendar: mayan (expected islamic, hebrew, persian or chinese)'
+  print_ok
+}
+
+dq_supports_chinese_new_years_and_leap_months() {
+  progress "dq supports Chinese new years and leap months"
+  result="$( $bin -c '[1985, 2000, 2020, 2023, 2024, 2025, 2026, 2033] | map(. as $y | from_calendar("chinese"; $y; 1; 1) | "\(.month)/\(.day)")' )"
+  assert_eq "$result" '["2/20","2/5","1/25","1/22","2/10","1/29","2/17","1/31"]'
+  result="$( $bin -r '[range(2017;2035)] | map(. as $y | [range(1;14)] | map(. as $m | try (from_calendar("chinese"; $y; $m; 1) | to_calendar("chinese") | select(.leapMonth) | "\($y):\(.monthCode)") catch empty)) | add | join(" ")' )"
+  assert_eq "$result" '2017:M06L 2020:M04L 2023:M02L 2025:M06L 2028:M05L 2031:M03L 2033:M11L'
+  print_ok
+}
+
+dq_supports_from_calendar_filter() {
+  progress "dq supports from_calendar() filter"
+  result="$( TZ=Asia/Tokyo $bin -r 'from_calendar("hebrew"; 5786; 1; 1) | .rfc3339' )"
+  assert_eq "$result" '2025-09-23T00:00:00+09:00'
+  result="$( $bin -c '[from_calendar("hebrew"; 5785; "M06"; 14), from_calendar("islamic"; 1446; 9; 1), from_calendar("persian"; 1404; 1; 1), from_calendar("chinese"; 2025; "M06L"; 1)] | map([.year, .month, .day])' )"
+  assert_eq "$result" '[[2025,3,14],[2025,3,1],[2025,3,21],[2025,7,25]]'
+  result="$( $bin -c 'from_ymd(2026;10;19) | [to_calendar("islamic", "hebrew", "persian", "chinese") as $c | from_calendar($c.calendar; $c.year; $c.monthCode; $c.day) | [.year, .month, .day]] | unique' )"
+  assert_eq "$result" '[[2026,10,19]]'
+  result="$( $bin 'from_calendar("persian"; 1404; 12; 30)' 2>&1 || true )"
+  assert_eq "$result" 'no such day in Esfand 1404 of persian calendar: 30'
+  result="$( $bin 'from_calendar("chinese"; 2025; "M07L"; 1)' 2>&1 || true )"
+  assert_eq "$result" 'no such month in 2025 of chinese calendar: M07L'
+  result="$( $bin 'from_calendar("chinese"; 99999999; 1; 1)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of chinese calendar: 99999999'
+  result="$( $bin 'from_calendar("islamic"; 999999999999; 1; 1)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of islamic calendar: 999999999999'
+  result="$( $bin 'from_calendar("hebrew"; 13761; 1; 1)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of hebrew calendar: 13761'
+  print_ok
+}
+
+dq_supports_fiscal_year_options() {
+  progress "dq supports --fiscal-year-start option"
+  result="$( $bin -c --fiscal-year-start 4 'from_ymd(2026;3;31) | .fiscal' )"
+  assert_eq "$result" '{"dayOfYear":365,"period":12,"quarter":4,"week":53,"year":2026}'
+  result="$( $bin -c --fiscal-year-start apr --fiscal-year-name start 'from_ymd(2026;4;1) | .fiscal' )"
+  assert_eq "$result" '{"dayOfYear":1,"period":1,"quarter":1,"week":1,"year":2026}'
+  result="$( $bin -c --fiscal-year-start October 'from_ymd(2025;10;19) | .fiscal' )"
+  assert_eq "$result" '{"dayOfYear":19,"period":1,"quarter":1,"week":4,"year":2026}'
+  result="$( $bin -c 'from_ymd(2025;10;19) | has("fiscal")' )"
+  assert_eq "$result" 'false'
+  result="$( $bin --fiscal-year-start 13 . 2>&1 || true )"
+  assert_eq "$result" 'invalid month for fiscal year start: 13'
+  print_ok
+}
+
+dq_supports_week_based_fiscal_calendars() {
+  progress "dq supports week-based fiscal calendars"
+  result="$( $bin -c --fiscal-year-start feb --fiscal-calendar 4-5-4 'from_ymd(2024;2;3) | .fiscal' )"
+  assert_eq "$result" '{"dayOfYear":371,"period":12,"quarter":4,"week":53,"year":2024}'
+  result="$( $bin -c --fiscal-year-start feb --fiscal-calendar 4-5-4 --fiscal-year-name start 'from_ymd(2025;10;19) | .fiscal' )"
+  assert_eq "$result" '{"dayOfYear":260,"period":9,"quarter":3,"week":38,"year":2025}'
+  result="$( $bin -c --fiscal-year-start jan --fiscal-calendar 4-4-5 '[from_ymd(2025;1;25), from_ymd(2025;1;26), from_ymd(2025;2;23), from_ymd(2025;3;30)] | map(.fiscal.period)' )"
+  assert_eq "$result" '[1,2,3,4]'
+  result="$( $bin -c --fiscal-year-start jan --fiscal-calendar 5-4-4 '[from_ymd(2025;2;1), from_ymd(2025;2;2)] | map(.fiscal.period)' )"
+  assert_eq "$result" '[1,2]'
+  print_ok
+}
+
+dq_supports_start_of_fiscal_and_end_of_fiscal_filters() {
+  progress "dq supports start_of_fiscal() and end_of_fiscal() filters"
+  result="$( TZ=Asia/Tokyo $bin -c --fiscal-year-start 10 'from_ymd(2025;11;19) | [start_of_fiscal("year", "quarter", "period", "week") | .rfc3339]' )"
+  assert_eq "$result" '["2025-10-01T00:00:00+09:00","2025-10-01T00:00:00+09:00","2025-11-01T00:00:00+09:00","2025-11-16T00:00:00+09:00"]'
+  result="$( TZ=Asia/Tokyo $bin -c --fiscal-year-start 10 'from_ymd(2025;11;19) | [end_of_fiscal("year", "quarter", "period", "week") | .rfc3339]' )"
+  assert_eq "$result" '["2026-09-30T23:59:59+09:00","2025-12-31T23:59:59+09:00","2025-11-30T23:59:59+09:00","2025-11-22T23:59:59+09:00"]'
+  result="$( $bin -c 'from_ymd(2025;11;19) | end_of_fiscal("quarter") | [.month, .day, .nanosecond]' )"
+  assert_eq "$result" '[12,31,999999999]'
+  result="$( $bin -c --fiscal-year-start feb --fiscal-calendar 4-5-4 'from_ymd(2025;11;19) | [start_of_fiscal("year", "period"), end_of_fiscal("year")] | map([.month, .day])' )"
+  assert_eq "$result" '[[2,2],[11,2],[1,31]]'
+  result="$( $bin 'from_ymd(2025;11;19) | start_of_fiscal("day")' 2>&1 || true )"
+  assert_eq "$result" 'unknown unit: day (expected year, quarter, period or week)'
+  print_ok
+}
+
+dq_supports_tz_list_filter() {
+  progress "dq supports tz_list filter"
+  zoneinfo="$(mktemp -d)"
+  mkdir -p "$zoneinfo/Asia" "$zoneinfo/Europe" "$zoneinfo/posix/Asia"
+  cp /usr/share/zoneinfo/Asia/Tokyo "$zoneinfo/Asia/Tokyo"
+  cp /usr/share/zoneinfo/Asia/Tokyo "$zoneinfo/posix/Asia/Tokyo"
+  cp /usr/share/zoneinfo/Europe/Berlin "$zoneinfo/Europe/Berlin"
+  echo 'not a zone' > "$zoneinfo/zone.tab"
+  result="$( ZONEINFO="$zoneinfo" $bin -c 'tz_list' )"
+  assert_eq "$result" '["Asia/Tokyo","Europe/Berlin"]'
+  rm -rf "$zoneinfo"
+  result="$( $bin 'tz_list | index("America/New_York") != null' )"
+  assert_eq "$result" 'true'
+  print_ok
+}
+
+dq_supports_tz_info_filter() {
+  progress "dq supports tz_info() filter"
+  result="$( $bin -c 'tz_info("Europe/Berlin") | [.name, .observesDst, .standard, .daylight, (.abbreviations | index("CEST") != null)]' )"
+  assert_eq "$result" '["Europe/Berlin",true,{"abbreviation":"CET","offsetSeconds":3600},{"abbreviation":"CEST","offsetSeconds":7200},true]'
+  result="$( $bin -c 'tz_info("Asia/Tokyo") | [.abbreviation, .offsetSeconds, .dst, .observesDst, .daylight]' )"
+  assert_eq "$result" '["JST",32400,false,false,null]'
+  result="$( $bin 'tz_info("Nowhere/City")' 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone Nowhere/City'
+  result="$( $bin 'tz_info("../../etc/passwd")' 2>&1 || true )"
+  assert_eq "$result" 'invalid zone name: ../../etc/passwd'
+  print_ok
+}
+
+dq_supports_tz_transitions_filter() {
+  progress "dq supports tz_transitions() filter"
+  result="$( $bin -c 'tz_transitions("Europe/Berlin"; from_ymdz(2027;1;1;"UTC"); from_ymdz(2028;1;1;"UTC")) | [.time.rfc3339, .before, .after]' )"
+  assert_eq "$result" '["2027-03-28T03:00:00+02:00",{"abbreviation":"CET","dst":false,"offsetSeconds":3600},{"abbreviation":"CEST","dst":true,"offsetSeconds":7200}]
+["2027-10-31T02:00:00+01:00",{"abbreviation":"CEST","dst":true,"offsetSeconds":7200},{"abbreviation":"CET","dst":false,"offsetSeconds":3600}]'
+  result="$( $bin -c '[tz_transitions("America/New_York"; from_ymdz(2006;1;1;"UTC"); from_ymdz(2008;1;1;"UTC")) | .time.rfc3339]' )"
+  assert_eq "$result" '["2006-04-02T03:00:00-04:00","2006-10-29T01:00:00-05:00","2007-03-11T03:00:00-04:00","2007-11-04T01:00:00-05:00"]'
+  result="$( $bin -c '[tz_transitions("Australia/Lord_Howe"; from_ymdz(2026;1;1;"UTC"); from_ymdz(2027;1;1;"UTC")) | .after.offsetSeconds]' )"
+  assert_eq "$result" '[37800,39600]'
+  result="$( $bin -c '[tz_transitions("Asia/Tokyo"; from_ymdz(1952;1;1;"UTC"); from_ymdz(2100;1;1;"UTC"))]' )"
+  assert_eq "$result" '[]'
+  result="$( ZONEINFO="$tzdata_zip" $bin -c '[tz_transitions("Europe/Berlin"; from_ymdz(2027;1;1;"UTC"); from_ymdz(2028;1;1;"UTC"))] | length' )"
+  assert_eq "$result" '2'
+  print_ok
+}
+
+dq_supports_tzdata_option() {
+  progress "dq supports --tzdata option"
+  tzdata="$(mktemp -d)"
+  mkdir -p "$tzdata/Asia"
+  cp /usr/share/zoneinfo/Asia/Tokyo "$tzdata/Asia/Tokyo"
+  echo '# version 2099z' > "$tzdata/tzdata.zi"
+  result="$( $bin --tzdata "$tzdata" -c '[tz_version, tz_list]' )"
+  assert_eq "$result" '["2099z",["Asia/Tokyo"]]'
+  result="$( DQ_TZDATA="$tzdata" $bin -c '[tz_version, tz_list]' )"
+  assert_eq "$result" '["2099z",["Asia/Tokyo"]]'
+  result="$( DQ_TZDATA="$tzdata" $bin -r 'from_ymdz(2025;1;1;"Asia/Tokyo") | .rfc3339' )"
+  assert_eq "$result" '2025-01-01T00:00:00+09:00'
+  result="$( DQ_TZDATA="$tzdata" $bin 'from_ymdz(2025;1;1;"Europe/Berlin")' 2>&1 || true )"
+  assert_eq "$result" "unable to load timezone 'Europe/Berlin': unknown time zone Europe/Berlin"
+  rm -rf "$tzdata"
+  result="$( $bin --tzdata "$tzdata_zip" -c '[tz_version, (tz_list | index("Europe/Berlin") != null)]' )"
+  assert_eq "$result" '["unknown",true]'
+  result="$( $bin --tzdata /nonexistent . 2>&1 || true )"
+  assert_eq "$result" 'unable to load tzdata: stat /nonexistent: no such file or directory'
+  print_ok
+}
+
+dq_shows_tzdata_version() {
+  progress "dq shows tzdata version"
+  result="$( $bin -r 'tz_version' )"
+  version="$( $bin --version )"
+  assert_eq "${version##* (}" "tzdata $result)"
+  result="$( ZONEINFO=/nonexistent $bin -r 'tz_version | test("^[0-9]{4}[a-z]$|^unknown$")' )"
+  assert_eq "$result" 'true'
+  print_ok
+}
+
+dq_supports_dst_policy_option() {
+  progress "dq supports --dst-policy option"
+  query='from_ymdhmsz(2025;11;2;1;30;0;"America/New_York"), from_ymdhmsz(2025;3;9;2;30;0;"America/New_York") | [.rfc3339, .dst_status]'
+  result="$( $bin -c "$query" )"
+  assert_eq "$result" '["2025-11-02T01:30:00-04:00","ambiguous"]
+["2025-03-09T03:30:00-04:00","gap"]'
+  result="$( $bin -c --dst-policy shift_forward "$query" )"
+  assert_eq "$result" '["2025-11-02T01:30:00-04:00","ambiguous"]
+["2025-03-09T03:30:00-04:00","gap"]'
+  result="$( $bin -c --dst-policy earlier "$query" )"
+  assert_eq "$result" '["2025-11-02T01:30:00-04:00","ambiguous"]
+["2025-03-09T01:30:00-05:00","gap"]'
+  result="$( $bin -c --dst-policy later "$query" )"
+  assert_eq "$result" '["2025-11-02T01:30:00-05:00","ambiguous"]
+["2025-03-09T03:30:00-04:00","gap"]'
+  result="$( $bin --dst-policy error 'from_ymdhmsz(2025;11;2;1;30;0;"America/New_York")' 2>&1 || true )"
+  assert_eq "$result" 'ambiguous local time in America/New_York: 2025-11-02 01:30:00'
+  result="$( $bin --dst-policy error 'from_ymdhmsz(2025;3;9;2;30;0;"America/New_York")' 2>&1 || true )"
+  assert_eq "$result" 'nonexistent local time in America/New_York: 2025-03-09 02:30:00'
+  result="$( $bin -c --dst-policy later 'from_ymdhmsz(2025;4;6;2;30;0;"Australia/Sydney"), from_ymdhmsz(2025;10;5;2;30;0;"Australia/Sydney") | [.rfc3339, .dst_status]' )"
+  assert_eq "$result" '["2025-04-06T02:30:00+10:00","ambiguous"]
+["2025-10-05T03:30:00+11:00","gap"]'
+  result="$( $bin -c 'from_ymdhmsz(2025;7;1;2;30;0;"America/New_York") | has("dst_status")' )"
+  assert_eq "$result" 'false'
+  result="$( TZ=America/Sao_Paulo $bin -c 'from_ymd(2018;11;4) | [.rfc3339, .dst_status]' )"
+  assert_eq "$result" '["2018-11-04T01:00:00-02:00","gap"]'
+  result="$( echo 'at 2025-11-02T01:30:00 and 2025-11-02T02:30:00' | $bin -R -c --assume-tz America/New_York --dst-policy later 'find_times | [.rfc3339, .dst_status]' )"
+  assert_eq "$result" '["2025-11-02T01:30:00-05:00","ambiguous"]
+["2025-11-02T02:30:00-05:00",null]'
+  print_ok
+}
+
+dq_supports_assume_tz_option() {
+  progress "dq supports --assume-tz option"
+  result="$( $bin -r 'fromansic("Fri Oct 28 05:59:07 2022") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07Z'
+  result="$( $bin -r --assume-tz Asia/Tokyo 'fromansic("Fri Oct 28 05:59:07 2022") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07+09:00'
+  result="$( $bin -r --assume-tz America/New_York 'guess("Fri Oct 28 05:59:07 2022") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07-04:00'
+  result="$( TZ=Asia/Tokyo $bin -r --assume-tz Local 'fromansic("Fri Oct 28 05:59:07 2022") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07+09:00'
+  result="$( $bin -r --assume-tz Asia/Tokyo 'fromrfc3339("2022-10-28T05:59:07Z") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07Z'
+  result="$( $bin -c --assume-tz America/New_York 'fromansic("Sun Nov  6 01:30:00 2022") | [.rfc3339, .dst_status]' )"
+  assert_eq "$result" '["2022-11-06T01:30:00-04:00","ambiguous"]'
+  result="$( $bin --assume-tz Nowhere/Unknown . 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone Nowhere/Unknown'
+  print_ok
+}
+
+dq_supports_parse_in_filter() {
+  progress "dq supports parse_in() filter"
+  result="$( $bin -r '"Fri Oct 28 05:59:07 2022" | parse_in("America/New_York") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07-04:00'
+  result="$( $bin -r '"Fri Oct 28 05:59:07 2022" | parse_in("Asia/Tokyo") | [.hour, .timezone.offsetSeconds] | @csv' )"
+  assert_eq "$result" '5,32400'
+  result="$( $bin -r '"Fri, 02 Dec 2022 05:59:07 IST" | parse_in("Asia/Jerusalem") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T05:59:07+02:00'
+  result="$( $bin -r '"2022-10-28T05:59:07Z" | parse_in("Asia/Tokyo") | .rfc3339' )"
+  assert_eq "$result" '2022-10-28T05:59:07Z'
+  result="$( $bin '"foo" | parse_in("Asia/Tokyo")' 2>&1 || true )"
+  assert_eq "$result" 'unable to guess'
+  print_ok
+}
+
+dq_resolves_tz_abbreviations() {
+  progress "dq resolves time zone abbreviations"
+  result="$( TZ=UTC $bin -r 'fromrfc1123("Fri, 02 Dec 2022 05:59:07 JST") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T05:59:07+09:00'
+  result="$( TZ=UTC $bin -r 'fromrfc1123("Fri, 02 Dec 2022 05:59:07 IST") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T05:59:07+05:30'
+  result="$( TZ=UTC $bin -r 'fromunixdate("Sat Jul  2 05:59:07 EST 2022") | .rfc3339' )"
+  assert_eq "$result" '2022-07-02T06:59:07-04:00'
+  result="$( TZ=UTC $bin -r 'fromrfc822("02 Dec 22 05:59 NZST") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T06:59:00+13:00'
+  result="$( TZ=UTC $bin -r 'fromrfc1123("Fri, 02 Dec 2022 05:59:07 GMT") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T05:59:07Z'
+  result="$( TZ=Europe/Dublin $bin -r 'fromrfc1123("Sat, 02 Jul 2022 05:59:07 IST") | .rfc3339' )"
+  assert_eq "$result" '2022-07-02T05:59:07+01:00'
+  result="$( TZ=UTC $bin -r --tz-abbreviation IST=Asia/Jerusalem 'fromrfc1123("Fri, 02 Dec 2022 05:59:07 IST") | .rfc3339' )"
+  assert_eq "$result" '2022-12-02T05:59:07+02:00'
+  result="$( TZ=UTC $bin -r 'fromunixdate("Sat Jul  2 05:59:07 +09 2022"), fromrfc1123("Fri, 02 Dec 2022 05:59:07 -03") | .rfc3339' )"
+  assert_eq "$result" '2022-07-02T05:59:07+09:00
+2022-12-02T05:59:07-03:00'
+  result="$( TZ=UTC $bin 'fromrfc1123("Fri, 02 Dec 2022 05:59:07 XYZ")' 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone abbreviation: XYZ'
+  result="$( TZ=UTC $bin 'guess("Fri, 02 Dec 2022 05:59:07 XYZ")' 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone abbreviation: XYZ'
+  result="$( $bin --tz-abbreviation IST . 2>&1 || true )"
+  assert_eq "$result" 'invalid time zone abbreviation: IST (expected ABBR=ZONE)'
+  print_ok
+}
+
+dq_supports_tz_option() {
+  progress "dq supports --tz option"
+  result="$( TZ=Asia/Tokyo $bin --tz America/New_York -c '[$__tz__, (from_ymd(2025;1;1) | .rfc3339), (from_ymdhms(2025;7;1;12;0;0) | .rfc3339), (fromunix(0) | .rfc3339)]' )"
+  assert_eq "$result" '["America/New_York","2025-01-01T00:00:00-05:00","2025-07-01T12:00:00-04:00","1969-12-31T19:00:00-05:00"]'
+  result="$( TZ=Asia/Tokyo DQ_TZ=UTC $bin -c '[$__tz__, (from_ymd(2025;1;1) | .rfc3339), (fromunix(0) | utc | local | .rfc3339)]' )"
+  assert_eq "$result" '["UTC","2025-01-01T00:00:00Z","1970-01-01T00:00:00Z"]'
+  result="$( DQ_TZ=UTC $bin --tz Europe/Berlin -r '$__tz__' )"
+  assert_eq "$result" 'Europe/Berlin'
+  result="$( TZ=Asia/Tokyo $bin -r '$__tz__' )"
+  assert_eq "$result" 'Asia/Tokyo'
+  result="$( TZ=Asia/Tokyo $bin --tz Europe/Berlin -c '[today, yesterday, tomorrow] | map([.hour, (.timezone.short | IN("CET", "CEST"))])' )"
+  assert_eq "$result" '[[0,true],[0,true],[0,true]]'
+  result="$( TZ=Asia/Tokyo $bin --tz Local -c '[$__tz__, (fromunix(0) | .rfc3339)]' )"
+  assert_eq "$result" '["Asia/Tokyo","1970-01-01T09:00:00+09:00"]'
+  result="$( $bin --tz Nowhere/Unknown . 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone Nowhere/Unknown'
+  print_ok
+}
+
+dq_supports_rfc2822_filters() {
+  progress "dq supports fromrfc2822 and torfc2822 filters"
+  result="$( $bin -r 'fromrfc2822("Fri, 21 Nov 1997 09:55:06 -0600 (CST)") | .rfc3339' )"
+  assert_eq "$result" '1997-11-21T09:55:06-06:00'
+  result="$( $bin -r 'from_rfc2822("Thu,\n      13\n        Feb\n          1969\n      23:32\n               -0330 (Newfoundland Time)") | .rfc3339' )"
+  assert_eq "$result" '1969-02-13T23:32:00-03:30'
+  result="$( $bin -r 'fromrfc2822("21 Nov 97 09:55:06 (a (nested) comment) EST") | .rfc3339' )"
+  assert_eq "$result" '1997-11-21T09:55:06-05:00'
+  result="$( $bin -r 'fromrfc2822("1 Jul 2003 10:52 GMT") | .rfc3339' )"
+  assert_eq "$result" '2003-07-01T10:52:00Z'
+  result="$( $bin 'fromrfc2822("1 Jul 2003 10:52 JST")' 2>&1 || true )"
+  assert_eq "$result" 'unknown time zone abbreviation: JST'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:01+09:00") | torfc2822, to_rfc2822' )"
+  assert_eq "$result" 'Sun, 23 Oct 2022 23:03:01 +0900
+Sun, 23 Oct 2022 23:03:01 +0900'
+  print_ok
+}
+
+dq_supports_httpdate_filters() {
+  progress "dq supports fromhttpdate and tohttpdate filters"
+  result="$( $bin -r '"Sun, 06 Nov 1994 08:49:37 GMT", "Sunday, 06-Nov-94 08:49:37 GMT", "Sun Nov  6 08:49:37 1994" | from_httpdate | .rfc3339' )"
+  assert_eq "$result" '1994-11-06T08:49:37Z
+1994-11-06T08:49:37Z
+1994-11-06T08:49:37Z'
+  result="$( $bin 'fromhttpdate("Sun, 06 Nov 1994 08:49:37 JST")' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as HTTP-date: Sun, 06 Nov 1994 08:49:37 JST'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:01+09:00") | tohttpdate' )"
+  assert_eq "$result" 'Sun, 23 Oct 2022 14:03:01 GMT'
+  print_ok
+}
+
+dq_supports_asn1_time_filters() {
+  progress "dq supports ASN.1 UTCTime and GeneralizedTime filters"
+  result="$( $bin -r '"221023140301Z", "4912312359Z", "500101000000Z", "221023230301+0900" | fromutctime | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T14:03:01Z
+2049-12-31T23:59:00Z
+1950-01-01T00:00:00Z
+2022-10-23T23:03:01+09:00'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:01+09:00") | toutctime' )"
+  assert_eq "$result" '221023140301Z'
+  result="$( $bin 'from_ymdz(2050;1;1;"UTC") | to_utctime' 2>&1 || true )"
+  assert_eq "$result" 'out of range of UTCTime (1950 to 2049): 2050-01-01T00:00:00Z'
+  result="$( $bin -c '"20221023140301.123Z", "20221023230301+0900" | from_generalizedtime | [.rfc3339, .nanosecond]' )"
+  assert_eq "$result" '["2022-10-23T14:03:01Z",123000000]
+["2022-10-23T23:03:01+09:00",0]'
+  result="$( $bin -r --assume-tz Asia/Tokyo 'fromgeneralizedtime("20221023230301") | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( $bin -r 'fromrfc3339nano("2022-10-23T23:03:01.120+09:00", "2022-10-23T23:03:01+09:00") | togeneralizedtime' )"
+  assert_eq "$result" '20221023140301.12Z
+20221023140301Z'
+  result="$( $bin 'fromgeneralizedtime("20221323140301Z")' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as GeneralizedTime: 20221323140301Z'
+  print_ok
+}
+
+dq_supports_sql_filters() {
+  progress "dq supports fromsql and tosql filters"
+  result="$( $bin -c '"2022-10-23 23:03:01.123456", "2022-10-23 23:03:01+09", "2022-10-23" | fromsql | [.rfc3339, .nanosecond]' )"
+  assert_eq "$result" '["2022-10-23T23:03:01Z",123456000]
+["2022-10-23T23:03:01+09:00",0]
+["2022-10-23T00:00:00Z",0]'
+  result="$( $bin -r --assume-tz Asia/Tokyo 'from_sql("2022-10-23 23:03:01") | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( $bin -r 'fromrfc3339nano("2022-10-23T23:03:01.123456789+09:00", "2022-10-23T23:03:01+09:00") | tosql' )"
+  assert_eq "$result" '2022-10-23 23:03:01.123456
+2022-10-23 23:03:01'
+  print_ok
+}
+
+dq_supports_gitraw_filters() {
+  progress "dq supports fromgitraw and togitraw filters"
+  result="$( $bin -r 'fromgitraw("1666533781 +0900"), from_gitraw("1666533781 -0230") | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00
+2022-10-23T11:33:01-02:30'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:01+09:00") | togitraw' )"
+  assert_eq "$result" '1666533781 +0900'
+  print_ok
+}
+
+dq_supports_w3c_and_atom_filters() {
+  progress "dq supports W3C-DTF and Atom filters"
+  result="$( $bin -r '"1997", "1997-07", "1997-07-16", "1997-07-16T19:20+01:00", "1997-07-16T19:20:30.45+01:00" | fromw3c | .rfc3339' )"
+  assert_eq "$result" '1997-01-01T00:00:00Z
+1997-07-01T00:00:00Z
+1997-07-16T00:00:00Z
+1997-07-16T19:20:00+01:00
+1997-07-16T19:20:30+01:00'
+  result="$( $bin 'fromw3c("1997-07-16T19:20")' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as W3C date: 1997-07-16T19:20'
+  result="$( $bin -r 'fromrfc3339nano("2022-10-23T23:03:01.12+09:00") | tow3c, toatom' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00
+2022-10-23T23:03:01.12+09:00'
+  result="$( $bin -c 'from_atom("2003-12-13t18:30:02.25z") | [.rfc3339, .nanosecond]' )"
+  assert_eq "$result" '["2003-12-13T18:30:02Z",250000000]'
+  print_ok
+}
+
+dq_guesses_named_formats() {
+  progress "dq guesses named formats"
+  result="$( $bin -r '"Fri, 21 Nov 1997 09:55:06 -0600 (CST)", "221023140301Z", "20221023140301.5Z", "2022-10-23 23:03:01", "1666533781 +0900", "1997-07-16T19:20+01:00" | guess | .rfc3339' )"
+  assert_eq "$result" '1997-11-21T09:55:06-06:00
+2022-10-23T14:03:01Z
+2022-10-23T14:03:01Z
+2022-10-23T23:03:01Z
+2022-10-23T23:03:01+09:00
+1997-07-16T19:20:00+01:00'
+  result="$( $bin -c '["2022", "2022-10-23", "20221023140301"] | map(try guess catch "unable")' )"
+  assert_eq "$result" '["unable","unable","unable"]'
+  print_ok
+}
+
+dq_supports_excel_and_ole_filters() {
+  progress "dq supports Excel serial date and OLE Automation date filters"
+  result="$( $bin -c '[1, 59.5, 61, 44857.75] | map(from_excel | .rfc3339)' )"
+  assert_eq "$result" '["1900-01-01T00:00:00Z","1900-02-28T12:00:00Z","1900-03-01T00:00:00Z","2022-10-23T18:00:00Z"]'
+  result="$( $bin 'from_excel(60)' 2>&1 || true )"
+  assert_eq "$result" 'nonexistent date in Excel 1900 date system (1900-02-29): 60'
+  result="$( $bin -c '[from_ymdz(1900;2;28;"UTC"), from_ymdz(1900;3;1;"UTC"), from_ymdhmsz(2022;10;23;18;0;0;"Asia/Tokyo")] | map(to_excel)' )"
+  assert_eq "$result" '[59,61,44857.75]'
+  result="$( $bin -r --assume-tz Asia/Tokyo 'from_excel("44857.75") | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T18:00:00+09:00'
+  result="$( $bin -c '[from_excel1904(43395.75) | .rfc3339, to_excel1904]' )"
+  assert_eq "$result" '["2022-10-23T18:00:00Z",43395.75]'
+  result="$( $bin -c '[-1.25, 0, 44857.25] | map(from_ole | [.rfc3339, to_ole])' )"
+  assert_eq "$result" '[["1899-12-29T06:00:00Z",-1.25],["1899-12-30T00:00:00Z",0],["2022-10-23T06:00:00Z",44857.25]]'
+  print_ok
+}
+
+dq_supports_tick_epoch_filters() {
+  progress "dq supports FILETIME, LDAP, .NET ticks and WebKit filters"
+  result="$( TZ=UTC $bin -r 'from_filetime(133110397810000000), from_ldap("133110397810000000"), from_ticks(638021629810000000), from_webkit(13311039781000000) | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01Z
+2022-10-23T23:03:01Z
+2022-10-23T23:03:01Z
+2022-10-23T23:03:01Z'
+  result="$( $bin -c 'fromrfc3339nano("2022-10-23T23:03:01.1234567Z") | [to_filetime, to_ldap, to_ticks, to_webkit]' )"
+  assert_eq "$result" '[133110397811234567,133110397811234567,638021629811234567,13311039781123456]'
+  result="$( TZ=UTC $bin -r 'from_filetime(0), from_ticks(0) | .rfc3339' )"
+  assert_eq "$result" '1601-01-01T00:00:00Z
+0001-01-01T00:00:00Z'
+  result="$( $bin 'from_filetime("foo")' 2>&1 || true )"
+  assert_eq "$result" 'expected number as input, but found: foo'
+  result="$( $bin 'from_filetime(1e300)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of FILETIME: 1e+300'
+  result="$( $bin 'from_jd(1e18)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of Julian Day: 1e+18'
+  result="$( TZ=UTC $bin 'from_unix(1e13) | to_ticks' 2>&1 || true )"
+  assert_eq "$result" 'out of range of .NET ticks: 318857-05-20T17:46:40Z'
+  result="$( $bin -r 'from_excel(2958465.5) | utc | .rfc3339' 2>&1 || true )"
+  assert_eq "$result" '9999-12-31T12:00:00Z'
+  result="$( $bin 'from_excel(2958466)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of Excel 1900 date system: 2958466'
+  print_ok
+}
+
+dq_supports_apple_and_julian_day_filters() {
+  progress "dq supports Mac absolute time and Julian Day filters"
+  result="$( TZ=UTC $bin -c 'from_apple(688258981.5) | [.rfc3339, .nanosecond, to_apple]' )"
+  assert_eq "$result" '["2022-10-23T23:03:01Z",500000000,688258981.5]'
+  result="$( TZ=UTC $bin -c '[from_jd(2459876.5), from_jd(0), from_mjd(59876), from_mjd(0)] | map(.rfc3339)' )"
+  assert_eq "$result" '["2022-10-24T00:00:00Z","-4713-11-24T12:00:00Z","2022-10-24T00:00:00Z","1858-11-17T00:00:00Z"]'
+  result="$( $bin -c 'from_ymdhmsz(2022;10;24;6;0;0;"UTC") | [to_jd, to_mjd]' )"
+  assert_eq "$result" '[2459876.75,59876.25]'
+  print_ok
+}
+
+dq_supports_epochs_option() {
+  progress "dq supports --epochs option"
+  result="$( $bin -c --epochs 'fromrfc3339("2022-10-23T23:03:01Z") | .epochs' )"
+  assert_eq "$result" '{"apple":688258981,"excel":44857.96042824074,"excel1904":43395.96042824074,"filetime":133110397810000000,"jd":2459876.4604282407,"mjd":59875.96042824074,"ole":44857.96042824074,"ticks":638021629810000000,"webkit":13311039781000000}'
+  result="$( $bin -c --epochs 'from_ymdz(1899;1;1;"UTC") | .epochs.excel' )"
+  assert_eq "$result" 'null'
+  result="$( $bin -c --epochs 'from_unix(1e13) | .epochs | [.filetime, .ticks, .webkit]' )"
+  assert_eq "$result" '[null,null,null]'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | has("epochs")' )"
+  assert_eq "$result" 'false'
+  print_ok
+}
+
+dq_supports_id_filters() {
+  progress "dq supports extracting times from identifiers"
+  result="$( TZ=UTC $bin -r '"C232AB00-9414-11EC-B3C8-9F6BDECED846", "{1ec9414c-232a-6b00-b3c8-9f6bdeced846}", "urn:uuid:017f22e2-79b0-7cc3-98c4-dc0c0c07398f", "017F22E279B07CC398C4DC0C0C07398F" | from_uuid | .rfc3339' )"
+  assert_eq "$result" '2022-02-22T19:22:22Z
+2022-02-22T19:22:22Z
+2022-02-22T19:22:22Z
+2022-02-22T19:22:22Z'
+  result="$( TZ=UTC $bin -r '"1ed52f0c-5287-11ed-9b6a-0242ac120002" | from_uuid | torfc3339nano' )"
+  assert_eq "$result" '2022-10-23T03:59:40.9703692Z'
+  result="$( TZ=UTC $bin 'from_uuid("f47ac10b-58cc-4372-a567-0e02b2c3d479")' 2>&1 || true )"
+  assert_eq "$result" 'UUID version 4 has no timestamp: f47ac10b-58cc-4372-a567-0e02b2c3d479'
+  result="$( TZ=UTC $bin 'from_uuid("1ed52f0c-5287-11ed-1b6a-0242ac120002")' 2>&1 || true )"
+  assert_eq "$result" 'not a UUID of the variant of RFC 4122: 1ed52f0c-5287-11ed-1b6a-0242ac120002'
+  result="$( TZ=UTC $bin -r '"01ARZ3NDEKTSV4RRFFQ69G5FAV", "01arz3ndektsv4rrffq69g5fav" | from_ulid | torfc3339nano' )"
+  assert_eq "$result" '2016-07-30T23:54:10.259Z
+2016-07-30T23:54:10.259Z'
+  result="$( TZ=UTC $bin 'from_ulid("8ZZZZZZZZZZZZZZZZZZZZZZZZZ")' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as ULID: 8ZZZZZZZZZZZZZZZZZZZZZZZZZ'
+  result="$( TZ=UTC $bin -r 'from_ksuid("0ujtsYcgvSTl8PAuAdqWYSMnLOv"), from_objectid("507f1f77bcf86cd799439011") | .rfc3339' )"
+  assert_eq "$result" '2017-10-10T04:00:47Z
+2012-10-17T21:13:27Z'
+  result="$( TZ=UTC $bin 'from_objectid("507f1f77bcf86cd79943901")' 2>&1 || true )"
+  assert_eq "$result" 'unable to parse as ObjectId: 507f1f77bcf86cd79943901'
+  print_ok
+}
+
+dq_supports_from_snowflake() {
+  progress "dq supports from_snowflake"
+  result="$( TZ=UTC $bin -r '"175928847299117063", 175928847299117063 | from_snowflake("discord") | torfc3339nano' )"
+  assert_eq "$result" '2016-04-30T11:18:25.796Z
+2016-04-30T11:18:25.796Z'
+  result="$( TZ=UTC $bin -r '1585430600497862656 | from_snowflake("twitter"), from_snowflake(1288834974657), from_snowflake(1288834974657 | from_unixmilli) | torfc3339nano' )"
+  assert_eq "$result" '2022-10-27T00:38:05.691Z
+2022-10-27T00:38:05.691Z
+2022-10-27T00:38:05.691Z'
+  result="$( TZ=UTC $bin '1 | from_snowflake("slack")' 2>&1 || true )"
+  assert_eq "$result" 'unknown Snowflake epoch: slack (expected twitter or discord)'
+  result="$( TZ=UTC $bin '"175928847299117063" | guess' 2>&1 || true )"
+  assert_eq "$result" 'unable to guess'
+  print_ok
+}
+
+dq_supports_guess_explain() {
+  progress "dq supports guess_explain"
+  result="$( TZ=UTC $bin -c '1666533781, 1666533781000, 1666533781.5, "2022-10-23T23:03:01Z", "Sun, 23 Oct 2022 23:03:01 +0900", "Sun, 23 Oct 2022 23:03:01 GMT" | guess_explain | [.format, .time.rfc3339]' )"
+  assert_eq "$result" '["unix","2022-10-23T14:03:01Z"]
+["unixmilli","2022-10-23T14:03:01Z"]
+["unix","2022-10-23T14:03:01Z"]
+["rfc3339","2022-10-23T23:03:01Z"]
+["rfc1123z","2022-10-23T23:03:01+09:00"]
+["rfc1123","2022-10-23T23:03:01Z"]'
+  result="$( TZ=UTC $bin -c '"C232AB00-9414-11EC-B3C8-9F6BDECED846", "1ec9414c-232a-6b00-b3c8-9f6bdeced846", "017f22e2-79b0-7cc3-98c4-dc0c0c07398f", "01ARZ3NDEKTSV4RRFFQ69G5FAV", "0ujtsYcgvSTl8PAuAdqWYSMnLOv", "507f1f77bcf86cd799439011" | guess_explain | [.format, .time.unix]' )"
+  assert_eq "$result" '["uuidv1",1645557742]
+["uuidv6",1645557742]
+["uuidv7",1645557742]
+["ulid",1469922850]
+["ksuid",1507608047]
+["objectid",1350508407]'
+  result="$( TZ=UTC $bin -r '"017f22e2-79b0-7cc3-98c4-dc0c0c07398f" | guess | .rfc3339' )"
+  assert_eq "$result" '2022-02-22T19:22:22Z'
+  result="$( TZ=UTC $bin 'guess_explain("f47ac10b-58cc-4372-a567-0e02b2c3d479")' 2>&1 || true )"
+  assert_eq "$result" 'unable to guess'
+  result="$( TZ=UTC $bin -c '["abcdefabcdefabcdefabcdef", "000000000000000000000000", "aWgEPTl1tmebfsQzFP4bxwgy80V", "12345678901234567890123456", "7ZZZZZZZZZZZZZZZZZZZZZZZZZ", "00000000-0000-7000-8000-000000000000"] | map(try guess catch .)' )"
+  assert_eq "$result" '["unable to guess","unable to guess","unable to guess","unable to guess","unable to guess","unable to guess"]'
+  print_ok
+}
+
+dq_decorate_times_keeps_ids() {
+  progress "dq --decorate-times keeps identifiers"
+  result="$( echo '{"id":"017f22e2-79b0-7cc3-98c4-dc0c0c07398f","at":"2022-10-23T23:03:01Z"}' | $bin -c --decorate-times '[.id, .at.year]' )"
+  assert_eq "$result" '["017f22e2-79b0-7cc3-98c4-dc0c0c07398f",2022]'
+  result="$( echo '{"id":"017f22e2-79b0-7cc3-98c4-dc0c0c07398f"}' | $bin -c --decorate-key '^id$' '.id.year' )"
+  assert_eq "$result" '2022'
+  print_ok
+}
+
+dq_supports_generating_ids() {
+  progress "dq supports generating identifiers from times"
+  result="$( $bin -c 'fromrfc3339nano("2022-10-23T23:03:01.123Z") | [to_uuidv7, to_ulid, to_ksuid, to_objectid] | map(guess_explain | [.format, .time.unixMilli])' )"
+  assert_eq "$result" '[["uuidv7",1666566181123],["ulid",1666566181123],["ksuid",1666566181000],["objectid",1666566181000]]'
+  result="$( $bin 'fromrfc3339("2022-10-23T23:03:01Z") | to_uuidv7 | test("^01840715-d088-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")' )"
+  assert_eq "$result" 'true'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | [to_ulid, to_ulid] | [.[0] != .[1], map(.[:10])]' )"
+  assert_eq "$result" '[true,["01GG3HBM48","01GG3HBM48"]]'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | [to_objectid, to_objectid] | [(map(.[:18]) | unique | length), .[0] != .[1]]' )"
+  assert_eq "$result" '[1,true]'
+  result="$( $bin 'from_ymdz(1969;12;31;"UTC") | to_ulid' 2>&1 || true )"
+  assert_eq "$result" 'out of range of ULID: 1969-12-31T00:00:00Z'
+  result="$( $bin 'from_ymdz(2014;1;1;"UTC") | to_ksuid' 2>&1 || true )"
+  assert_eq "$result" 'out of range of KSUID: 2014-01-01T00:00:00Z'
+  print_ok
+}
+
+dq_supports_seed_option() {
+  progress "dq supports --seed option"
+  result="$( $bin -r --seed 42 'fromrfc3339("2022-10-23T23:03:01Z") | to_ulid, to_uuidv7, to_ksuid, to_objectid, to_objectid' )"
+  assert_eq "$result" '01GG3HBM48QWDSFEWZ9ET75T4Z
+01840715-d088-79d9-b43e-92ba09dd9d52
+2GYWpf5jMB2CPwHUQtTPgquVlQ4
+6355c8254c888535841acbe0
+6355c8254c888535841acbe1'
+  result="$( $bin -r --seed 42 'fromrfc3339("2022-10-23T23:03:01Z") | to_ulid' )"
+  assert_eq "$result" '01GG3HBM48QWDSFEWZ9ET75T4Z'
+  result="$( $bin -r --seed 43 'fromrfc3339("2022-10-23T23:03:01Z") | to_ulid' )"
+  assert_eq "$result" '01GG3HBM48FM5RNR0CK4C0N8FS'
+  print_ok
+}
+
+dq_supports_tai_filters() {
+  progress "dq supports to_tai and from_tai"
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | [.leapSecondsBefore, (to_tai | .rfc3339)]' )"
+  assert_eq "$result" '[27,"2022-10-23T23:03:38Z"]'
+  result="$( $bin -c '["1970-01-01T00:00:00Z", "1972-01-01T00:00:00Z", "2016-12-31T23:59:59Z", "2017-01-01T00:00:00Z"] | map(fromrfc3339 | [.leapSecondsBefore, (to_tai | .rfc3339)])' )"
+  assert_eq "$result" '[[0,"1970-01-01T00:00:10Z"],[0,"1972-01-01T00:00:10Z"],[26,"2017-01-01T00:00:35Z"],[27,"2017-01-01T00:00:37Z"]]'
+  result="$( $bin -c '["2017-01-01T00:00:35Z", "2017-01-01T00:00:36Z", "2017-01-01T00:00:37Z"] | map(from_tai | .rfc3339)' )"
+  assert_eq "$result" '["2016-12-31T23:59:59Z","2017-01-01T00:00:00Z","2017-01-01T00:00:00Z"]'
+  result="$( $bin -r 'fromrfc3339("2022-10-23T23:03:38+09:00") | from_tai | .rfc3339' )"
+  assert_eq "$result" '2022-10-23T23:03:01+09:00'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | to_tai | [.scale, (from_tai | has("scale")), (try to_tai catch .), (try to_gps catch .)]' )"
+  assert_eq "$result" '["tai",false,"expected time in UTC as input, but found time in TAI","expected time in UTC as input, but found time in TAI"]'
+  result="$( $bin 'from_tai(1)' 2>&1 || true )"
+  assert_eq "$result" 'expected time or string as input, but found unexpected type: int'
+  print_ok
+}
+
+dq_supports_ut1_filters() {
+  progress "dq supports to_ut1 and from_ut1"
+  result="$( $bin -c 'fromrfc3339("2022-10-24T08:03:01+09:00") | to_ut1 | [.scale, .rfc3339]' )"
+  assert_eq "$result" '["ut1","2022-10-23T23:03:01Z"]'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | to_ut1 | [(from_ut1 | has("scale"), .rfc3339), (try to_ut1 catch .), (try to_tai catch .), (try to_gps catch .)]' )"
+  assert_eq "$result" '[false,"2022-10-23T23:03:01Z","expected time in UTC as input, but found time in UT1","expected time in UTC as input, but found time in UT1","expected time in UTC as input, but found time in UT1"]'
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z") | to_tai | try to_ut1 catch .' )"
+  assert_eq "$result" '"expected time in UTC as input, but found time in TAI"'
+  result="$( $bin 'from_ut1(1)' 2>&1 || true )"
+  assert_eq "$result" 'expected time or string as input, but found unexpected type: int'
+  print_ok
+}
+
+dq_supports_gps_filters() {
+  progress "dq supports to_gps and from_gps"
+  result="$( $bin -c 'fromrfc3339("2022-10-23T23:03:01Z"), fromrfc3339nano("2022-10-23T23:03:01.5Z"), fromrfc3339("1980-01-06T00:00:00Z") | to_gps' )"
+  assert_eq "$result" '{"seconds":1350601399,"timeOfWeek":82999,"week":2233}
+{"seconds":1350601399.5,"timeOfWeek":82999.5,"week":2233}
+{"seconds":0,"timeOfWeek":0,"week":0}'
+  result="$( TZ=UTC $bin -r 'from_gps(1350601399), from_gps("1350601399.25"), from_gps(2233; 82999), from_gps({week: 2233, timeOfWeek: 82999.5}) | torfc3339nano' )"
+  assert_eq "$result" '2022-10-23T23:03:01Z
+2022-10-23T23:03:01.25Z
+2022-10-23T23:03:01Z
+2022-10-23T23:03:01.5Z'
+  result="$( $bin 'fromrfc3339("1980-01-05T00:00:00Z") | to_gps' 2>&1 || true )"
+  assert_eq "$result" 'out of range of GPS time: 1980-01-05T00:00:00Z'
+  result="$( $bin 'from_gps(2233; 604800)' 2>&1 || true )"
+  assert_eq "$result" 'out of range of time of week: 604800'
+  print_ok
+}
+
+dq_supports_leap_seconds_option() {
+  progress "dq supports --leap-seconds option"
+  list="$(mktemp)"
+  cat > "$list" <<LIST
+# leap-seconds.list with a hypothetical leap second
+#$	 3676924800
+2272060800	10	# 1 Jan 1972
+3692217600	37	# 1 Jan 2017
+4007750400	38	# 1 Jan 2027
+LIST
+  result="$( $bin --leap-seconds "$list" -c '["2026-12-31T23:59:59Z", "2027-01-01T00:00:00Z"] | map(fromrfc3339 | [.leapSecondsBefore, (to_tai | .rfc3339), to_gps.seconds])' )"
+  assert_eq "$result" '[[27,"2027-01-01T00:00:36Z",1482796817],[28,"2027-01-01T00:00:38Z",1482796819]]'
+  result="$( DQ_LEAP_SECONDS="$list" $bin -c 'fromrfc3339("2027-01-01T00:00:00Z") | .leapSecondsBefore' )"
+  assert_eq "$result" '28'
+  echo '4007750400' >> "$list"
+  result="$( $bin --leap-seconds "$list" . 2>&1 || true )"
+  assert_eq "$result" "invalid leap second table: $list:6: 4007750400"
+  rm -f "$list"
+  print_ok
+}
+
 # basics
 dq_without_arguments
 dq_with_a_simple_filter
@@ -818,4 +1918,120 @@ dq_supports_tomorrowutc
 # regression test for utc | .unix | strftime("...")
 dq_regression_test_for_utc_unix_strftime
 
+# find_times() / --extract-times
+dq_supports_find_times_filter
+dq_supports_extract_times_option
+
+# --logfmt / --time-key
+dq_supports_logfmt_option
+dq_supports_time_key_option
+
+# --syslog
+dq_supports_syslog_option_for_rfc5424
+dq_supports_syslog_option_for_rfc3164
+
+# --decorate-times / --decorate-key / --decorate-path
+dq_supports_decorate_times_option
+dq_supports_decorate_key_option
+dq_supports_decorate_path_option
+
+# --time-output / --time-layout
+dq_supports_time_output_option
+dq_supports_time_layout_option
+
+# time objects in jq builtins
+dq_time_objects_survive_json_builtins
+dq_time_objects_survive_encoding_builtins
+dq_time_objects_survive_object_builtins
+dq_time_objects_can_be_compared
+
+# ordering of time objects
+dq_sorts_times_by_instant
+dq_regards_same_instants_as_equal_in_unique_and_group_by
+dq_supports_before_after_between_and_same_instant_filters
+
+# operators
+dq_supports_arithmetic_operators_on_times_and_durations
+dq_reports_meaningless_arithmetic_on_times
+dq_supports_comparison_operators_on_times_and_durations
+
+# duration arithmetic
+dq_supports_negation_and_abs_of_durations
+dq_supports_adding_and_scaling_durations
+dq_supports_truncate_and_round_filters
+dq_supports_fractional_durations_and_weeks_and_days
+dq_supports_components_of_durations
+
+# diff() / age()
+dq_supports_diff_filter
+dq_supports_end_of_month_options_of_diff_filter
+dq_supports_age_filter
+
+# humanize()
+dq_supports_humanize_filter_for_durations
+dq_supports_humanize_filter_for_times
+
+# --locale / format_locale()
+dq_supports_locale_option
+dq_takes_locale_from_environment_variables
+dq_supports_format_locale_filter
+
+# to_wareki() / from_wareki() / --era
+dq_supports_to_wareki_filter
+dq_supports_from_wareki_filter
+dq_supports_era_option
+
+# to_calendar() / from_calendar()
+dq_supports_to_calendar_filter
+dq_supports_chinese_new_years_and_leap_months
+dq_supports_from_calendar_filter
+
+# fiscal years
+dq_supports_fiscal_year_options
+dq_supports_week_based_fiscal_calendars
+dq_supports_start_of_fiscal_and_end_of_fiscal_filters
+
+# tz_list / tz_info() / tz_transitions()
+dq_supports_tz_list_filter
+dq_supports_tz_info_filter
+dq_supports_tz_transitions_filter
+
+# --tzdata / tz_version
+dq_supports_tzdata_option
+dq_shows_tzdata_version
+
+dq_supports_dst_policy_option
+
+dq_supports_assume_tz_option
+dq_supports_parse_in_filter
+dq_resolves_tz_abbreviations
+
+dq_supports_tz_option
+
+dq_supports_rfc2822_filters
+dq_supports_httpdate_filters
+dq_supports_asn1_time_filters
+dq_supports_sql_filters
+dq_supports_gitraw_filters
+dq_supports_w3c_and_atom_filters
+dq_guesses_named_formats
+
+dq_supports_excel_and_ole_filters
+dq_supports_tick_epoch_filters
+dq_supports_apple_and_julian_day_filters
+dq_supports_epochs_option
+
+dq_supports_id_filters
+dq_supports_from_snowflake
+dq_supports_guess_explain
+dq_decorate_times_keeps_ids
+
+dq_supports_generating_ids
+dq_supports_seed_option
+
+dq_supports_tai_filters
+dq_supports_ut1_filters
+dq_supports_gps_filters
+dq_supports_leap_seconds_option
+
 test_result=0